@@ -0,0 +1,9 @@
+package migrate
+
+import "embed"
+
+// Primary holds the migrations of the primary database so they ship inside
+// the binary.
+//
+//go:embed primary/*.sql
+var Primary embed.FS
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "calculated_estimate_items";
+DROP TABLE IF EXISTS "calculated_estimates";
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS "calculated_estimates" (
+  id uuid NOT NULL,
+  user_id uuid NOT NULL,
+  user_latitude float NOT NULL,
+  user_longitude float NOT NULL,
+  total_price numeric(12,2) NOT NULL,
+  estimated_delivery_time_in_minutes int NOT NULL,
+  created_at timestamp NOT NULL,
+  PRIMARY KEY ("id"),
+  FOREIGN KEY ("user_id") REFERENCES "user_details" ("user_id") ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "calculated_estimate_items" (
+  estimate_id uuid NOT NULL,
+  merchant_id uuid NOT NULL,
+  product_id uuid NOT NULL,
+  is_starting_point boolean NOT NULL DEFAULT false,
+  quantity int NOT NULL,
+  price numeric(10,2) NOT NULL,
+  PRIMARY KEY ("estimate_id", "product_id"),
+  FOREIGN KEY ("estimate_id") REFERENCES "calculated_estimates" ("id") ON DELETE CASCADE,
+  FOREIGN KEY ("merchant_id") REFERENCES "merchants" ("id") ON DELETE CASCADE,
+  FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "orders" (
+  id uuid NOT NULL,
+  user_id uuid NOT NULL,
+  estimate_id uuid NOT NULL,
+  created_at timestamp NOT NULL,
+  PRIMARY KEY ("id"),
+  UNIQUE ("estimate_id"),
+  FOREIGN KEY ("user_id") REFERENCES "user_details" ("user_id") ON DELETE CASCADE,
+  FOREIGN KEY ("estimate_id") REFERENCES "calculated_estimates" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "orders_user_id_created_at_idx" ON "orders" ("user_id", "created_at" DESC);
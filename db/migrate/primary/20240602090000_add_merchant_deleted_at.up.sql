@@ -0,0 +1 @@
+ALTER TABLE "merchants" ADD COLUMN IF NOT EXISTS deleted_at timestamp;
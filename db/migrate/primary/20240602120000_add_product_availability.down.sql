@@ -0,0 +1,2 @@
+ALTER TABLE "products" DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE "products" DROP COLUMN IF EXISTS is_available;
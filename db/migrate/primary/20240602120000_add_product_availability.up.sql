@@ -0,0 +1,2 @@
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS is_available boolean NOT NULL DEFAULT true;
+ALTER TABLE "products" ADD COLUMN IF NOT EXISTS deleted_at timestamp;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS "refresh_tokens" (
+  id uuid NOT NULL,
+  user_id uuid NOT NULL,
+  family_id uuid NOT NULL,
+  role varchar(10) NOT NULL,
+  token_hash char(64) NOT NULL,
+  expires_at timestamptz NOT NULL,
+  revoked_at timestamptz,
+  created_at timestamptz NOT NULL,
+  PRIMARY KEY ("id"),
+  UNIQUE ("token_hash"),
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "refresh_tokens_user_id_idx" ON "refresh_tokens" ("user_id");
+CREATE INDEX IF NOT EXISTS "refresh_tokens_family_id_idx" ON "refresh_tokens" ("family_id");
+
+CREATE TABLE IF NOT EXISTS "revoked_access_tokens" (
+  jti uuid NOT NULL,
+  expires_at timestamptz NOT NULL,
+  PRIMARY KEY ("jti")
+);
+
+CREATE TABLE IF NOT EXISTS "user_token_revocations" (
+  user_id uuid NOT NULL,
+  revoked_before timestamptz NOT NULL,
+  PRIMARY KEY ("user_id"),
+  FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
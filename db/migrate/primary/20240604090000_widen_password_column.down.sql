@@ -0,0 +1,2 @@
+alter table "admin_details" alter column "password" type varchar(100);
+alter table "user_details" alter column "password" type varchar(100);
@@ -0,0 +1,3 @@
+-- argon2id hashes in PHC format do not reliably fit in 100 characters
+alter table "admin_details" alter column "password" type varchar(255);
+alter table "user_details" alter column "password" type varchar(255);
@@ -0,0 +1,22 @@
+create table if not exists "login_attempts" (
+  "id" uuid not null,
+  "username" varchar(30) not null,
+  "ip_address" varchar(45) not null,
+  "succeeded" boolean not null,
+  "created_at" timestamptz not null,
+  primary key ("id")
+);
+
+create index if not exists "login_attempts_username_created_at_idx"
+  on "login_attempts" ("username", "created_at");
+
+-- one row per username and per client ip, counting failures since the last
+-- success or since the failure window lapsed.
+create table if not exists "login_lockouts" (
+  "scope" varchar(10) not null,
+  "key" varchar(45) not null,
+  "failed_count" int not null,
+  "last_failed_at" timestamptz not null,
+  "locked_until" timestamptz,
+  primary key ("scope", "key")
+);
@@ -0,0 +1,3 @@
+drop table if exists "user_action_tokens";
+alter table "user_details" drop column if exists "email_verified_at";
+alter table "admin_details" drop column if exists "email_verified_at";
@@ -0,0 +1,25 @@
+alter table "admin_details" add column if not exists "email_verified_at" timestamptz;
+alter table "user_details" add column if not exists "email_verified_at" timestamptz;
+
+-- accounts from before verification existed keep ordering; only new ones
+-- have to verify.
+update "admin_details" set "email_verified_at" = now() where "email_verified_at" is null;
+update "user_details" set "email_verified_at" = now() where "email_verified_at" is null;
+
+-- single use tokens mailed to the user, only their sha256 is stored.
+create table if not exists "user_action_tokens" (
+  "id" uuid not null,
+  "user_id" uuid not null,
+  "role" varchar(10) not null,
+  "purpose" varchar(20) not null,
+  "token_hash" char(64) not null,
+  "expires_at" timestamptz not null,
+  "used_at" timestamptz,
+  "created_at" timestamptz not null,
+  primary key ("id"),
+  unique ("token_hash"),
+  foreign key ("user_id") references "users" ("id") on delete cascade
+);
+
+create index if not exists "user_action_tokens_user_id_idx"
+  on "user_action_tokens" ("user_id", "purpose");
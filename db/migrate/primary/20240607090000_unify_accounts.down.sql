@@ -0,0 +1,57 @@
+alter table "user_action_tokens" add column if not exists "role" varchar(10) not null default 'user';
+alter table "refresh_tokens" add column if not exists "role" varchar(10) not null default 'user';
+
+create table if not exists "admin_details" (
+  "user_id" uuid not null,
+  "email" varchar(255) not null,
+  "password" varchar(255) not null,
+  "email_verified_at" timestamptz,
+  primary key ("user_id"),
+  foreign key ("user_id") references "users" ("id") on delete cascade,
+  unique ("email")
+);
+
+create table if not exists "user_details" (
+  "user_id" uuid not null,
+  "email" varchar(255) not null,
+  "password" varchar(255) not null,
+  "email_verified_at" timestamptz,
+  primary key ("user_id"),
+  foreign key ("user_id") references "users" ("id") on delete cascade,
+  unique ("email")
+);
+
+insert into "admin_details" ("user_id", "email", "password", "email_verified_at")
+select u."id", u."email", u."password", u."email_verified_at"
+from "users" u
+inner join "user_roles" r on r."user_id" = u."id" and r."role" = 'admin';
+
+insert into "user_details" ("user_id", "email", "password", "email_verified_at")
+select u."id", u."email", u."password", u."email_verified_at"
+from "users" u
+inner join "user_roles" r on r."user_id" = u."id" and r."role" = 'user';
+
+alter table "merchants"
+  drop constraint "merchants_user_id_fkey",
+  add constraint "merchants_user_id_fkey"
+    foreign key ("user_id") references "admin_details" ("user_id") on delete cascade;
+alter table "products"
+  drop constraint "products_user_id_fkey",
+  add constraint "products_user_id_fkey"
+    foreign key ("user_id") references "admin_details" ("user_id") on delete cascade;
+alter table "calculated_estimates"
+  drop constraint "calculated_estimates_user_id_fkey",
+  add constraint "calculated_estimates_user_id_fkey"
+    foreign key ("user_id") references "user_details" ("user_id") on delete cascade;
+alter table "orders"
+  drop constraint "orders_user_id_fkey",
+  add constraint "orders_user_id_fkey"
+    foreign key ("user_id") references "user_details" ("user_id") on delete cascade;
+
+drop table if exists "user_roles";
+
+alter table "users"
+  drop constraint if exists "users_email_key",
+  drop column if exists "email_verified_at",
+  drop column if exists "password",
+  drop column if exists "email";
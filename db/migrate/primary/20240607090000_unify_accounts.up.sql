@@ -0,0 +1,76 @@
+-- one credential record per account, admin and user become roles of it.
+do $$
+declare
+  duplicates int;
+begin
+  select count(*) into duplicates
+  from admin_details ad
+  inner join user_details ud on ud.email = ad.email and ud.user_id <> ad.user_id;
+
+  if duplicates > 0 then
+    raise exception
+      '% email(s) belong to both an admin and a different user account, give them distinct emails before migrating',
+      duplicates;
+  end if;
+end $$;
+
+alter table "users"
+  add column if not exists "email" varchar(255),
+  add column if not exists "password" varchar(255),
+  add column if not exists "email_verified_at" timestamptz;
+
+update "users" u
+set
+  "email" = d."email",
+  "password" = d."password",
+  "email_verified_at" = d."email_verified_at"
+from (
+  select "user_id", "email", "password", "email_verified_at" from "admin_details"
+  union all
+  select "user_id", "email", "password", "email_verified_at" from "user_details"
+) d
+where d."user_id" = u."id";
+
+alter table "users"
+  alter column "email" set not null,
+  alter column "password" set not null,
+  add constraint "users_email_key" unique ("email");
+
+create table if not exists "user_roles" (
+  "user_id" uuid not null,
+  "role" varchar(10) not null,
+  "created_at" timestamptz not null default now(),
+  primary key ("user_id", "role"),
+  foreign key ("user_id") references "users" ("id") on delete cascade
+);
+
+insert into "user_roles" ("user_id", "role")
+select "user_id", 'admin' from "admin_details"
+union all
+select "user_id", 'user' from "user_details";
+
+-- merchants and items belonged to admin_details, estimates and orders to
+-- user_details; they now point at the account itself.
+alter table "merchants"
+  drop constraint "merchants_user_id_fkey",
+  add constraint "merchants_user_id_fkey"
+    foreign key ("user_id") references "users" ("id") on delete cascade;
+alter table "products"
+  drop constraint "products_user_id_fkey",
+  add constraint "products_user_id_fkey"
+    foreign key ("user_id") references "users" ("id") on delete cascade;
+alter table "calculated_estimates"
+  drop constraint "calculated_estimates_user_id_fkey",
+  add constraint "calculated_estimates_user_id_fkey"
+    foreign key ("user_id") references "users" ("id") on delete cascade;
+alter table "orders"
+  drop constraint "orders_user_id_fkey",
+  add constraint "orders_user_id_fkey"
+    foreign key ("user_id") references "users" ("id") on delete cascade;
+
+drop table "admin_details";
+drop table "user_details";
+
+-- roles are read from user_roles whenever tokens are issued.
+alter table "refresh_tokens" drop column if exists "role";
+alter table "user_action_tokens" drop column if exists "role";
@@ -0,0 +1,10 @@
+delete from "login_lockouts" where length("key") > 45;
+alter table "login_lockouts" alter column "key" type varchar(45);
+update "login_attempts" set "username" = left("username", 30) where length("username") > 30;
+alter table "login_attempts" alter column "username" type varchar(30);
+
+drop index if exists "users_email_lower_key";
+drop index if exists "users_username_lower_key";
+
+alter table "users" add constraint "users_username_key" unique ("username");
+alter table "users" add constraint "users_email_key" unique ("email");
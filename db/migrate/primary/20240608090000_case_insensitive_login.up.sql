@@ -0,0 +1,31 @@
+do $$
+declare
+  usernames int;
+  emails int;
+begin
+  select count(*) into usernames from (
+    select lower("username") from "users" group by 1 having count(*) > 1
+  ) d;
+  select count(*) into emails from (
+    select lower("email") from "users" group by 1 having count(*) > 1
+  ) d;
+
+  if usernames > 0 or emails > 0 then
+    raise exception
+      '% username(s) and % email(s) only differ in case between accounts, rename them before migrating',
+      usernames,
+      emails;
+  end if;
+end $$;
+
+alter table "users" drop constraint if exists "users_username_key";
+alter table "users" drop constraint if exists "users_email_key";
+
+create unique index if not exists "users_username_lower_key" on "users" (lower("username"));
+create unique index if not exists "users_email_lower_key" on "users" (lower("email"));
+
+-- failed logins are tracked by the lowercased username, or the email when
+-- it does not belong to any account.
+alter table "login_attempts" alter column "username" type varchar(255);
+alter table "login_lockouts" alter column "key" type varchar(255);
+update "login_lockouts" set "key" = lower("key") where "scope" = 'username';
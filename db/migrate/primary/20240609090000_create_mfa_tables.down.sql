@@ -0,0 +1,3 @@
+drop table if exists "mfa_settings";
+drop table if exists "user_recovery_codes";
+drop table if exists "user_mfa";
@@ -0,0 +1,35 @@
+-- the totp secret is stored encrypted with MFA_ENCRYPTION_KEY. A row
+-- without confirmed_at is an enrolment that has not been completed yet.
+create table if not exists "user_mfa" (
+  "user_id" uuid not null,
+  "secret" varchar(255) not null,
+  "confirmed_at" timestamptz,
+  "last_used_step" bigint not null default 0,
+  "created_at" timestamptz not null,
+  primary key ("user_id"),
+  foreign key ("user_id") references "users" ("id") on delete cascade
+);
+
+-- single use codes to log in without the authenticator, only their sha256
+-- is stored.
+create table if not exists "user_recovery_codes" (
+  "id" uuid not null,
+  "user_id" uuid not null,
+  "code_hash" char(64) not null,
+  "used_at" timestamptz,
+  "created_at" timestamptz not null,
+  primary key ("id"),
+  unique ("user_id", "code_hash"),
+  foreign key ("user_id") references "users" ("id") on delete cascade
+);
+
+-- a single row holding the instance wide two-factor policy.
+create table if not exists "mfa_settings" (
+  "id" boolean not null default true,
+  "required_for_admins" boolean not null default false,
+  "updated_at" timestamptz not null default now(),
+  primary key ("id"),
+  check ("id")
+);
+
+insert into "mfa_settings" ("id") values (true) on conflict do nothing;
@@ -0,0 +1,2 @@
+drop index if exists "products_merchant_id_created_at_id_idx";
+drop index if exists "merchants_user_id_created_at_id_idx";
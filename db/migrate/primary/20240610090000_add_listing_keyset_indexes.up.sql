@@ -0,0 +1,8 @@
+-- keyset pagination seeks on the default sort, newest first with the id
+-- breaking ties, within one owner's merchants or one merchant's items.
+create index if not exists "merchants_user_id_created_at_id_idx"
+  on "merchants" ("user_id", "created_at" desc, "id" desc)
+  where "deleted_at" is null;
+create index if not exists "products_merchant_id_created_at_id_idx"
+  on "products" ("merchant_id", "created_at" desc, "id" desc)
+  where "deleted_at" is null;
@@ -0,0 +1,4 @@
+drop index if exists "products_name_trgm_idx";
+drop index if exists "merchants_name_trgm_idx";
+
+drop extension if exists pg_trgm;
@@ -0,0 +1,10 @@
+create extension if not exists pg_trgm;
+
+-- /search matches names with the word similarity operator <%, which these
+-- indexes answer without scanning every row.
+create index if not exists "merchants_name_trgm_idx"
+  on "merchants" using gin ("name" gin_trgm_ops)
+  where "deleted_at" is null;
+create index if not exists "products_name_trgm_idx"
+  on "products" using gin ("name" gin_trgm_ops)
+  where "deleted_at" is null;
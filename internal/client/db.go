@@ -3,14 +3,16 @@ package client
 import (
 	"context"
 	"fmt"
+	"log"
+	"time"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nozzlium/belimang/internal/config"
 )
 
 func InitDB(
 	cfg config.DBConfig,
-) (*pgx.Conn, error) {
+) (*pgxpool.Pool, error) {
 	dbURI := fmt.Sprintf(
 		"postgres://%s:%s@%s:%s/%s?%s",
 		cfg.DBUsername,
@@ -21,13 +23,61 @@ func InitDB(
 		cfg.DBParams,
 	)
 
-	conn, err := pgx.Connect(
-		context.Background(),
+	poolConfig, err := pgxpool.ParseConfig(
 		dbURI,
 	)
 	if err != nil {
 		return nil, err
 	}
+	poolConfig.MaxConns = cfg.DBMaxConns
+	poolConfig.MinConns = cfg.DBMinConns
+	poolConfig.MaxConnLifetime = cfg.DBMaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.DBMaxConnIdleTime
+	poolConfig.HealthCheckPeriod = cfg.DBHealthCheckPeriod
+
+	pool, err := pgxpool.NewWithConfig(
+		context.Background(),
+		poolConfig,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// the pool connects lazily, so ping until the database answers to
+	// surface connection problems at startup instead of on the first request.
+	backoff := cfg.DBConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err = pingDB(pool)
+		if err == nil {
+			return pool, nil
+		}
+		if attempt >= cfg.DBConnectAttempts {
+			pool.Close()
+			return nil, fmt.Errorf(
+				"failed to connect to database after %d attempts: %w",
+				attempt,
+				err,
+			)
+		}
+
+		log.Printf(
+			"failed to connect to database (attempt %d/%d), retrying in %s: %v",
+			attempt,
+			cfg.DBConnectAttempts,
+			backoff,
+			err,
+		)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func pingDB(pool *pgxpool.Pool) error {
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		5*time.Second,
+	)
+	defer cancel()
 
-	return conn, nil
+	return pool.Ping(ctx)
 }
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minJWTSecretLength = 32
+
+// AuthConfig is shared by token signing in the user service and token
+// verification in the middleware, so both always agree on the keys.
+type AuthConfig struct {
+	SigningMethod    jwt.SigningMethod
+	SigningKey       interface{}
+	SigningKeyID     string
+	VerificationKeys map[string]VerificationKey
+	JWTSecret        []byte
+	AccessTokenTTL   time.Duration
+	RefreshTokenTTL  time.Duration
+	Issuer           string
+	Audience         string
+}
+
+type VerificationKey struct {
+	Method jwt.SigningMethod
+	Key    crypto.PublicKey
+}
+
+// Auth validates the auth related settings and builds an AuthConfig.
+func (c Config) Auth() (AuthConfig, error) {
+	if c.AccessTokenTTL <= 0 || c.RefreshTokenTTL <= c.AccessTokenTTL {
+		return AuthConfig{}, errors.New(
+			"JWT_REFRESH_TOKEN_TTL must be longer than a positive JWT_ACCESS_TOKEN_TTL",
+		)
+	}
+
+	if c.JWTIssuer == "" || c.JWTAudience == "" {
+		return AuthConfig{}, errors.New(
+			"JWT_ISSUER and JWT_AUDIENCE must not be empty",
+		)
+	}
+
+	authConfig := AuthConfig{
+		AccessTokenTTL:  c.AccessTokenTTL,
+		RefreshTokenTTL: c.RefreshTokenTTL,
+		Issuer:          c.JWTIssuer,
+		Audience:        c.JWTAudience,
+	}
+
+	if c.JWTSigningKeyFile == "" {
+		if len(c.JWTSecret) < minJWTSecretLength {
+			return AuthConfig{}, fmt.Errorf(
+				"JWT_SECRET must be at least %d characters long",
+				minJWTSecretLength,
+			)
+		}
+		authConfig.SigningMethod = jwt.SigningMethodHS256
+		authConfig.SigningKey = []byte(c.JWTSecret)
+		authConfig.JWTSecret = []byte(c.JWTSecret)
+		return authConfig, nil
+	}
+
+	if c.JWTSigningKeyID == "" {
+		return AuthConfig{}, errors.New(
+			"JWT_SIGNING_KEY_ID is required with JWT_SIGNING_KEY_FILE",
+		)
+	}
+	signingKey, publicKey, err := loadPrivateKey(
+		c.JWTSigningKeyFile,
+	)
+	if err != nil {
+		return AuthConfig{}, err
+	}
+	authConfig.SigningKey = signingKey
+	authConfig.SigningKeyID = c.JWTSigningKeyID
+	authConfig.SigningMethod, err = signingMethodFor(publicKey)
+	if err != nil {
+		return AuthConfig{}, err
+	}
+
+	authConfig.VerificationKeys = map[string]VerificationKey{
+		c.JWTSigningKeyID: {
+			Method: authConfig.SigningMethod,
+			Key:    publicKey,
+		},
+	}
+	for kid, path := range c.JWTVerificationKeyFiles {
+		if _, ok := authConfig.VerificationKeys[kid]; ok {
+			return AuthConfig{}, fmt.Errorf(
+				"verification key id %q is used more than once",
+				kid,
+			)
+		}
+		publicKey, err := loadPublicKey(path)
+		if err != nil {
+			return AuthConfig{}, err
+		}
+		method, err := signingMethodFor(publicKey)
+		if err != nil {
+			return AuthConfig{}, err
+		}
+		authConfig.VerificationKeys[kid] = VerificationKey{
+			Method: method,
+			Key:    publicKey,
+		}
+	}
+
+	return authConfig, nil
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists every active verification key. It is empty in secret mode
+// since a shared secret must never be published.
+func (c AuthConfig) JWKS() JWKSet {
+	keys := make(
+		[]JWK,
+		0,
+		len(c.VerificationKeys),
+	)
+	for kid, verificationKey := range c.VerificationKeys {
+		switch key := verificationKey.Key.(type) {
+		case ed25519.PublicKey:
+			keys = append(
+				keys,
+				JWK{
+					Kty: "OKP",
+					Use: "sig",
+					Alg: jwt.SigningMethodEdDSA.Alg(),
+					Kid: kid,
+					Crv: "Ed25519",
+					X:   base64.RawURLEncoding.EncodeToString(key),
+				},
+			)
+		case *rsa.PublicKey:
+			keys = append(
+				keys,
+				JWK{
+					Kty: "RSA",
+					Use: "sig",
+					Alg: jwt.SigningMethodRS256.Alg(),
+					Kid: kid,
+					N: base64.RawURLEncoding.EncodeToString(
+						key.N.Bytes(),
+					),
+					E: base64.RawURLEncoding.EncodeToString(
+						big.NewInt(int64(key.E)).Bytes(),
+					),
+				},
+			)
+		}
+	}
+
+	return JWKSet{Keys: keys}
+}
+
+func signingMethodFor(
+	publicKey crypto.PublicKey,
+) (jwt.SigningMethod, error) {
+	switch publicKey.(type) {
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	default:
+		return nil, fmt.Errorf(
+			"unsupported key type %T, use an Ed25519 or RSA key",
+			publicKey,
+		)
+	}
+}
+
+func loadPrivateKey(
+	path string,
+) (interface{}, crypto.PublicKey, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if edKey, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes); err == nil {
+		privateKey := edKey.(ed25519.PrivateKey)
+		return privateKey, privateKey.Public(), nil
+	}
+	if rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes); err == nil {
+		return rsaKey, &rsaKey.PublicKey, nil
+	}
+
+	return nil, nil, fmt.Errorf(
+		"%s does not hold an Ed25519 or RSA private key",
+		path,
+	)
+}
+
+func loadPublicKey(
+	path string,
+) (crypto.PublicKey, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if edKey, err := jwt.ParseEdPublicKeyFromPEM(pemBytes); err == nil {
+		return edKey, nil
+	}
+	if rsaKey, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes); err == nil {
+		return rsaKey, nil
+	}
+
+	return nil, fmt.Errorf(
+		"%s does not hold an Ed25519 or RSA public key",
+		path,
+	)
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthRequiresALongEnoughSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{"missing", "", true},
+		{"too short", strings.Repeat("s", minJWTSecretLength-1), true},
+		{"long enough", strings.Repeat("s", minJWTSecretLength), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{
+				JWTSecret:       tt.secret,
+				AccessTokenTTL:  15 * time.Minute,
+				RefreshTokenTTL: time.Hour,
+				JWTIssuer:       "belimang",
+				JWTAudience:     "belimang",
+			}
+			_, err := cfg.Auth()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Auth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,9 +1,47 @@
 package config
 
+import (
+	"fmt"
+	"time"
+)
+
 type Config struct {
 	DB         DBConfig
+	Delivery   DeliveryConfig
+	Login      LoginConfig
+	Mail       MailConfig
+	Search     SearchConfig
 	JWTSecret  string `json:"JWT_SECRET"`
-	BCryptSalt uint8  `json:"BCRYPT_SALT"`
+	BCryptSalt uint8  `json:"BCRYPT_SALT" envDefault:"10"`
+
+	PasswordHashAlgorithm string `json:"PASSWORD_HASH_ALGORITHM" envDefault:"argon2id"`
+	Argon2Memory          uint32 `json:"ARGON2_MEMORY_KIB" envDefault:"65536"`
+	Argon2Iterations      uint32 `json:"ARGON2_ITERATIONS" envDefault:"3"`
+	Argon2Parallelism     uint8  `json:"ARGON2_PARALLELISM" envDefault:"2"`
+
+	AccessTokenTTL  time.Duration `json:"JWT_ACCESS_TOKEN_TTL" envDefault:"15m"`
+	RefreshTokenTTL time.Duration `json:"JWT_REFRESH_TOKEN_TTL" envDefault:"720h"`
+	JWTIssuer       string        `json:"JWT_ISSUER" envDefault:"belimang"`
+	JWTAudience     string        `json:"JWT_AUDIENCE" envDefault:"belimang"`
+
+	// JWTSigningKeyFile switches signing from HS256 to EdDSA or RS256 with
+	// the PEM encoded private key, advertised under JWTSigningKeyID.
+	// JWTVerificationKeyFiles maps the kid of retired keys to their PEM
+	// encoded public keys so tokens signed before a rotation stay valid.
+	JWTSigningKeyFile       string            `json:"JWT_SIGNING_KEY_FILE"`
+	JWTSigningKeyID         string            `json:"JWT_SIGNING_KEY_ID"`
+	JWTVerificationKeyFiles map[string]string `json:"JWT_VERIFICATION_KEY_FILES" envKeyValSeparator:"="`
+
+	// MFAEncryptionKey encrypts the totp secrets stored in the database. It
+	// is a base64 encoded 32 byte key and must not change once secrets
+	// have been stored with it.
+	MFAEncryptionKey     string        `json:"MFA_ENCRYPTION_KEY"`
+	MFAIssuer            string        `json:"MFA_ISSUER" envDefault:"BeliMang"`
+	MFAPendingTokenTTL   time.Duration `json:"MFA_PENDING_TOKEN_TTL" envDefault:"5m"`
+	MFARecoveryCodeCount int           `json:"MFA_RECOVERY_CODE_COUNT" envDefault:"10"`
+
+	// MigrateOnStart applies pending embedded migrations before serving.
+	MigrateOnStart bool `json:"MIGRATE_ON_START" envDefault:"false"`
 }
 
 type DBConfig struct {
@@ -13,4 +51,83 @@ type DBConfig struct {
 	DBUsername string `json:"DB_USERNAME"`
 	DBPassword string `json:"DB_PASSWORD"`
 	DBParams   string `json:"DB_PARAMS"`
+
+	// pool settings apply per process, so with prefork enabled the database
+	// sees up to DBMaxConns connections for every child.
+	DBMaxConns          int32         `json:"DB_MAX_CONNS" envDefault:"10"`
+	DBMinConns          int32         `json:"DB_MIN_CONNS" envDefault:"0"`
+	DBMaxConnLifetime   time.Duration `json:"DB_MAX_CONN_LIFETIME" envDefault:"1h"`
+	DBMaxConnIdleTime   time.Duration `json:"DB_MAX_CONN_IDLE_TIME" envDefault:"30m"`
+	DBHealthCheckPeriod time.Duration `json:"DB_HEALTH_CHECK_PERIOD" envDefault:"1m"`
+	DBConnectAttempts   int           `json:"DB_CONNECT_ATTEMPTS" envDefault:"5"`
+	DBConnectBackoff    time.Duration `json:"DB_CONNECT_BACKOFF" envDefault:"1s"`
+}
+
+type DeliveryConfig struct {
+	CourierSpeedKmh float64 `json:"DELIVERY_COURIER_SPEED_KMH" envDefault:"40"`
+	MaxRadiusKm     float64 `json:"DELIVERY_MAX_RADIUS_KM" envDefault:"3"`
+}
+
+func (c DeliveryConfig) Validate() error {
+	if c.CourierSpeedKmh <= 0 {
+		return fmt.Errorf(
+			"DELIVERY_COURIER_SPEED_KMH must be positive, got %v",
+			c.CourierSpeedKmh,
+		)
+	}
+	if c.MaxRadiusKm <= 0 {
+		return fmt.Errorf(
+			"DELIVERY_MAX_RADIUS_KM must be positive, got %v",
+			c.MaxRadiusKm,
+		)
+	}
+
+	return nil
+}
+
+// SearchConfig sets how closely a name has to resemble a search, as the
+// pg_trgm word similarity from 0 to 1. Lower values tolerate more typos
+// and return more noise.
+type SearchConfig struct {
+	MinSimilarity float64 `json:"SEARCH_MIN_SIMILARITY" envDefault:"0.3"`
+}
+
+func (c SearchConfig) Validate() error {
+	if c.MinSimilarity < 0 || c.MinSimilarity > 1 {
+		return fmt.Errorf(
+			"SEARCH_MIN_SIMILARITY must be between 0 and 1, got %v",
+			c.MinSimilarity,
+		)
+	}
+
+	return nil
+}
+
+// LoginConfig locks a username out for LockoutBase once it reaches
+// MaxFailures failed logins within FailureWindow, doubling the lockout on
+// every further failure up to LockoutMax. Client ips get the same
+// treatment with their own, usually higher, threshold.
+type LoginConfig struct {
+	MaxFailures      int           `json:"LOGIN_MAX_FAILURES" envDefault:"5"`
+	MaxFailuresPerIP int           `json:"LOGIN_MAX_FAILURES_PER_IP" envDefault:"20"`
+	FailureWindow    time.Duration `json:"LOGIN_FAILURE_WINDOW" envDefault:"15m"`
+	LockoutBase      time.Duration `json:"LOGIN_LOCKOUT_BASE" envDefault:"1m"`
+	LockoutMax       time.Duration `json:"LOGIN_LOCKOUT_MAX" envDefault:"1h"`
+}
+
+type MailConfig struct {
+	// Driver is either "log", which writes mails to LogFile (or stdout),
+	// or "smtp".
+	Driver       string `json:"MAIL_DRIVER" envDefault:"log"`
+	From         string `json:"MAIL_FROM" envDefault:"no-reply@belimang.local"`
+	LogFile      string `json:"MAIL_LOG_FILE"`
+	SMTPHost     string `json:"SMTP_HOST"`
+	SMTPPort     int    `json:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername string `json:"SMTP_USERNAME"`
+	SMTPPassword string `json:"SMTP_PASSWORD"`
+
+	// AppURL is the frontend the links in mails point to.
+	AppURL                string        `json:"APP_URL" envDefault:"http://localhost:3000"`
+	VerificationTokenTTL  time.Duration `json:"EMAIL_VERIFICATION_TOKEN_TTL" envDefault:"24h"`
+	PasswordResetTokenTTL time.Duration `json:"PASSWORD_RESET_TOKEN_TTL" envDefault:"1h"`
 }
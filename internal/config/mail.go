@@ -0,0 +1,43 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/nozzlium/belimang/internal/mailer"
+)
+
+func (c Config) Mailer() (mailer.Mailer, error) {
+	switch c.Mail.Driver {
+	case "log":
+		if c.Mail.LogFile == "" {
+			return mailer.NewLogMailer(os.Stdout), nil
+		}
+		file, err := os.OpenFile(
+			c.Mail.LogFile,
+			os.O_CREATE|os.O_APPEND|os.O_WRONLY,
+			0o600,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return mailer.NewLogMailer(file), nil
+	case "smtp":
+		if c.Mail.SMTPHost == "" {
+			return nil, errors.New("SMTP_HOST is required with MAIL_DRIVER=smtp")
+		}
+		return mailer.NewSMTPMailer(
+			c.Mail.SMTPHost,
+			c.Mail.SMTPPort,
+			c.Mail.SMTPUsername,
+			c.Mail.SMTPPassword,
+			c.Mail.From,
+		), nil
+	default:
+		return nil, fmt.Errorf(
+			"MAIL_DRIVER must be log or smtp, got %q",
+			c.Mail.Driver,
+		)
+	}
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"time"
+)
+
+const mfaEncryptionKeyLength = 32
+
+type MFAConfig struct {
+	EncryptionKey     []byte
+	Issuer            string
+	PendingTokenTTL   time.Duration
+	RecoveryCodeCount int
+}
+
+// MFA validates the two-factor settings and builds an MFAConfig.
+func (c Config) MFA() (MFAConfig, error) {
+	key, err := base64.StdEncoding.DecodeString(c.MFAEncryptionKey)
+	if err != nil || len(key) != mfaEncryptionKeyLength {
+		return MFAConfig{}, errors.New(
+			"MFA_ENCRYPTION_KEY must be a base64 encoded 32 byte key, generate one with `openssl rand -base64 32`",
+		)
+	}
+
+	if c.MFAIssuer == "" {
+		return MFAConfig{}, errors.New("MFA_ISSUER must not be empty")
+	}
+	if c.MFAPendingTokenTTL <= 0 {
+		return MFAConfig{}, errors.New("MFA_PENDING_TOKEN_TTL must be positive")
+	}
+	if c.MFARecoveryCodeCount <= 0 {
+		return MFAConfig{}, errors.New("MFA_RECOVERY_CODE_COUNT must be positive")
+	}
+
+	return MFAConfig{
+		EncryptionKey:     key,
+		Issuer:            c.MFAIssuer,
+		PendingTokenTTL:   c.MFAPendingTokenTTL,
+		RecoveryCodeCount: c.MFARecoveryCodeCount,
+	}, nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nozzlium/belimang/internal/password"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher builds the hasher new passwords are hashed with. The other
+// algorithm stays available to verify existing hashes, which are upgraded
+// on the next successful login.
+func (c Config) PasswordHasher() (password.Hasher, error) {
+	cost := int(c.BCryptSalt)
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf(
+			"BCRYPT_SALT must be between %d and %d",
+			bcrypt.MinCost,
+			bcrypt.MaxCost,
+		)
+	}
+	bcryptHasher := password.Bcrypt{
+		Cost: cost,
+	}
+
+	if c.Argon2Memory < 8*uint32(c.Argon2Parallelism) ||
+		c.Argon2Iterations < 1 ||
+		c.Argon2Parallelism < 1 {
+		return nil, errors.New(
+			"ARGON2_ITERATIONS and ARGON2_PARALLELISM must be positive and ARGON2_MEMORY_KIB at least 8 per thread",
+		)
+	}
+	argon2idHasher := password.Argon2id{
+		Memory:      c.Argon2Memory,
+		Iterations:  c.Argon2Iterations,
+		Parallelism: c.Argon2Parallelism,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+
+	switch c.PasswordHashAlgorithm {
+	case "argon2id":
+		return password.NewChain(
+			argon2idHasher,
+			bcryptHasher,
+		), nil
+	case "bcrypt":
+		return password.NewChain(
+			bcryptHasher,
+			argon2idHasher,
+		), nil
+	default:
+		return nil, fmt.Errorf(
+			"PASSWORD_HASH_ALGORITHM must be argon2id or bcrypt, got %q",
+			c.PasswordHashAlgorithm,
+		)
+	}
+}
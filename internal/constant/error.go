@@ -18,6 +18,14 @@ var (
 		"unauthorized",
 	)
 
+	ErrTokenRevoked = errors.New(
+		"token has been revoked",
+	)
+
+	ErrForbidden = errors.New(
+		"forbidden",
+	)
+
 	ErrInvalidBody = errors.New(
 		"invalid body",
 	)
@@ -45,4 +53,20 @@ var (
 	ErrInvalidChange = errors.New(
 		"invalid change",
 	)
+
+	ErrTooFar = errors.New(
+		"merchants are too far from user location",
+	)
+
+	ErrOrderExists = errors.New(
+		"order already placed for this estimate",
+	)
+
+	ErrEmailNotVerified = errors.New(
+		"email address is not verified",
+	)
+
+	ErrTooManyAttempts = errors.New(
+		"too many failed login attempts, try again later",
+	)
 )
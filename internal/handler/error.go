@@ -1,53 +1,150 @@
 package handler
 
 import (
+	"errors"
 	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/validation"
 )
 
+// ErrorBody is the envelope of every error response. Code is stable for
+// clients to branch on, while Message is meant for humans and may change.
+type ErrorBody struct {
+	Code      string                  `json:"code"`
+	Message   string                  `json:"message"`
+	RequestID string                  `json:"requestId,omitempty"`
+	Fields    []validation.FieldError `json:"fields,omitempty"`
+}
+
+type errorMapping struct {
+	target error
+	status int
+	code   string
+}
+
+// errorMappings is checked in order with errors.Is, so wrapped errors map
+// the same as the sentinels they wrap. Requests that are well formed but
+// break a business rule get 422.
+var errorMappings = []errorMapping{
+	{constant.ErrNotFound, fiber.StatusNotFound, "not_found"},
+	{constant.ErrTokenRevoked, fiber.StatusUnauthorized, "token_revoked"},
+	{constant.ErrUnauthorized, fiber.StatusUnauthorized, "unauthorized"},
+	{constant.ErrForbidden, fiber.StatusForbidden, "forbidden"},
+	{constant.ErrEmailNotVerified, fiber.StatusForbidden, "email_not_verified"},
+	{constant.ErrConflict, fiber.StatusConflict, "conflict"},
+	{constant.ErrOrderExists, fiber.StatusConflict, "order_exists"},
+	{constant.ErrProductExists, fiber.StatusConflict, "product_exists"},
+	{constant.ErrTooManyAttempts, fiber.StatusTooManyRequests, "too_many_attempts"},
+	{constant.ErrInsufficientFund, fiber.StatusUnprocessableEntity, "insufficient_fund"},
+	{constant.ErrInvalidChange, fiber.StatusUnprocessableEntity, "invalid_change"},
+	{constant.ErrInsufficientStock, fiber.StatusUnprocessableEntity, "insufficient_stock"},
+	{constant.ErrTooFar, fiber.StatusUnprocessableEntity, "too_far"},
+	{constant.ErrInvalidBody, fiber.StatusBadRequest, "invalid_body"},
+	{constant.ErrBadInput, fiber.StatusBadRequest, "bad_input"},
+}
+
+// HandleError logs the detail of err and answers with an ErrorBody. The
+// message of errors that are not mapped never reaches the client.
 func HandleError(
 	ctx *fiber.Ctx,
 	err ErrorResponse,
 ) error {
-	log.Printf("error: %v", err.detail)
-	switch err.error {
-	case constant.ErrNotFound:
-		return ctx.Status(fiber.StatusNotFound).
-			JSON(fiber.Map{
-				"message": err.message,
-			})
-	case constant.ErrConflict:
-		return ctx.Status(fiber.StatusConflict).
-			JSON(fiber.Map{
-				"message": err.message,
-			})
-	case constant.ErrBadInput,
-		constant.ErrInvalidBody,
-		constant.ErrInsufficientFund,
-		constant.ErrInvalidChange,
-		constant.ErrInsufficientStock:
-		return ctx.Status(fiber.StatusBadRequest).
-			JSON(fiber.Map{
-				"message": err.message,
-			})
-	default:
+	requestID := ctx.GetRespHeader(fiber.HeaderXRequestID)
+	log.Printf("error [%s]: %v", requestID, err.detail)
+
+	status, body := toErrorBody(err.error)
+	body.RequestID = requestID
+
+	var lockoutErr model.LockoutError
+	if errors.As(err.error, &lockoutErr) {
+		retryAfter := math.Ceil(
+			time.Until(lockoutErr.LockedUntil).Seconds(),
+		)
+		ctx.Set(
+			fiber.HeaderRetryAfter,
+			strconv.Itoa(max(int(retryAfter), 1)),
+		)
+	}
+
+	if status == fiber.StatusInternalServerError {
 		log.Printf(
-			"internal error: %v",
+			"internal error [%s]: %v",
+			requestID,
 			err.error,
 		)
-		return ctx.Status(fiber.StatusInternalServerError).
-			JSON(fiber.Map{
-				"message": "internal server error",
-			})
+	}
+
+	return ctx.Status(status).
+		JSON(body)
+}
+
+// ErrorHandler renders errors returned by middlewares and routing, such as
+// an unknown route, with the same envelope as HandleError.
+func ErrorHandler(
+	ctx *fiber.Ctx,
+	err error,
+) error {
+	return HandleError(
+		ctx,
+		ErrorResponse{
+			error:  err,
+			detail: err.Error(),
+		},
+	)
+}
+
+func toErrorBody(err error) (int, ErrorBody) {
+	var validationErr validation.Error
+	if errors.As(err, &validationErr) {
+		return fiber.StatusBadRequest, ErrorBody{
+			Code:    "validation_failed",
+			Message: constant.ErrBadInput.Error(),
+			Fields:  validationErr.Fields,
+		}
+	}
+
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.target) {
+			return mapping.status, ErrorBody{
+				Code:    mapping.code,
+				Message: mapping.target.Error(),
+			}
+		}
+	}
+
+	// errors fiber raises itself, such as 404 for unknown routes or 413 for
+	// oversized bodies, keep their status.
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) &&
+		fiberErr.Code < fiber.StatusInternalServerError {
+		message := utils.StatusMessage(fiberErr.Code)
+		return fiberErr.Code, ErrorBody{
+			Code: strings.ReplaceAll(
+				strings.ToLower(message),
+				" ",
+				"_",
+			),
+			Message: strings.ToLower(message),
+		}
+	}
+
+	return fiber.StatusInternalServerError, ErrorBody{
+		Code:    "internal_error",
+		Message: "internal server error",
 	}
 }
 
 type ErrorResponse struct {
-	error   error
-	message string
-	detail  string
+	error  error
+	detail string
 }
 
 func (e ErrorResponse) Error() string {
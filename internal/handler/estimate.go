@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/service"
+)
+
+type EstimateHandler struct {
+	estimateService *service.EstimateService
+}
+
+func NewEstimateHandler(
+	estimateService *service.EstimateService,
+) *EstimateHandler {
+	return &EstimateHandler{
+		estimateService: estimateService,
+	}
+}
+
+func (h *EstimateHandler) Estimate(
+	ctx *fiber.Ctx,
+) error {
+	var body model.EstimateRequestBody
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[estimate] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	estimateModel, err := body.IsValid()
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[estimate] failed to validate body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	estimateResp, err := h.estimateService.Estimate(
+		ctx.Context(),
+		estimateModel,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[estimate] failed to calculate estimate: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(estimateResp)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/nozzlium/belimang/internal/constant"
 	"github.com/nozzlium/belimang/internal/model"
 	"github.com/nozzlium/belimang/internal/service"
@@ -27,12 +28,11 @@ func (h *MerchantHandler) Create(
 	var body model.MerchantRequestBody
 	err := ctx.BodyParser(&body)
 	if err != nil {
-		err = constant.ErrBadInput
+		err = constant.ErrInvalidBody
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[create merchant] failed to parse body: %v",
 					err,
@@ -46,8 +46,7 @@ func (h *MerchantHandler) Create(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[create merchant] failed to validate body: %v",
 					err,
@@ -64,8 +63,7 @@ func (h *MerchantHandler) Create(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[create merchant] failed creating merchant: %v",
 					err,
@@ -93,10 +91,81 @@ func (h *MerchantHandler) FindAll(
 		"offset",
 		0,
 	)
+	if err := queries.IsValid(); err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find merchant] invalid queries: %v",
+					err,
+				),
+			},
+		)
+	}
 	queries.CreatedAt = ctx.Query(
 		"createdAt",
 		"desc",
 	)
+	if err := queries.ParseSort(); err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find merchant] invalid sort: %v",
+					err,
+				),
+			},
+		)
+	}
+	queries.CursorQueries = model.CursorQueries{
+		Mode: model.PaginationMode(ctx.Query(
+			"pagination",
+			string(model.OffsetPagination),
+		)),
+		Cursor: ctx.Query("cursor"),
+		Total: model.TotalMode(ctx.Query(
+			"total",
+			string(model.TotalNone),
+		)),
+	}
+	if err := queries.ParseCursor(); err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find merchant] invalid cursor: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	if queries.IsCursor() {
+		merchantData, meta, err := h.merchantService.FindPage(
+			ctx.Context(),
+			queries,
+		)
+		if err != nil {
+			return HandleError(
+				ctx,
+				ErrorResponse{
+					error: err,
+					detail: fmt.Sprintf(
+						"[find merchant] failed to find merchants: %v",
+						err,
+					),
+				},
+			)
+		}
+
+		return ctx.JSON(fiber.Map{
+			"data": merchantData,
+			"meta": meta,
+		})
+	}
 
 	merchantData, total, err := h.merchantService.FindAll(
 		ctx.Context(),
@@ -106,8 +175,7 @@ func (h *MerchantHandler) FindAll(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[find merchant] failed to find merchants: %v",
 					err,
@@ -125,3 +193,283 @@ func (h *MerchantHandler) FindAll(
 		},
 	})
 }
+
+func (h *MerchantHandler) FindNearby(
+	ctx *fiber.Ctx,
+) error {
+	var queries model.MerchantNearbyQueries
+	ctx.QueryParser(&queries.MerchantQueries)
+	queries.Limit = ctx.QueryInt(
+		"limit",
+		5,
+	)
+	queries.Offset = ctx.QueryInt(
+		"offset",
+		0,
+	)
+
+	if err := queries.IsValid(); err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find nearby merchant] invalid queries: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	err := queries.ParseCoordinates(
+		ctx.Params("coordinates"),
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find nearby merchant] failed to parse coordinates: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	nearbyData, total, err := h.merchantService.FindNearby(
+		ctx.Context(),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find nearby merchant] failed to find merchants: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data": nearbyData,
+		"meta": fiber.Map{
+			"limit":  queries.Limit,
+			"offset": queries.Offset,
+			"total":  total,
+		},
+	})
+}
+
+func (h *MerchantHandler) FindTrashed(
+	ctx *fiber.Ctx,
+) error {
+	var queries model.MerchantQueries
+	ctx.QueryParser(&queries)
+	queries.Limit = ctx.QueryInt(
+		"limit",
+		5,
+	)
+	queries.Offset = ctx.QueryInt(
+		"offset",
+		0,
+	)
+	if err := queries.IsValid(); err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find trashed merchant] invalid queries: %v",
+					err,
+				),
+			},
+		)
+	}
+	queries.CreatedAt = ctx.Query(
+		"createdAt",
+		"desc",
+	)
+	if err := queries.ParseSort(); err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find trashed merchant] invalid sort: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	merchantData, total, err := h.merchantService.FindTrashed(
+		ctx.Context(),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find trashed merchant] failed to find merchants: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data": merchantData,
+		"meta": fiber.Map{
+			"limit":  queries.Limit,
+			"offset": queries.Offset,
+			"total":  total,
+		},
+	})
+}
+
+func (h *MerchantHandler) Update(
+	ctx *fiber.Ctx,
+) error {
+	merchantId, err := uuid.Parse(
+		ctx.Params("merchantId"),
+	)
+	if err != nil {
+		err = constant.ErrNotFound
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[update merchant] failed to parse merchantId: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	var body model.MerchantPatchRequestBody
+	err = ctx.BodyParser(&body)
+	if err != nil || body.IsEmpty() {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[update merchant] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	merchantData, err := h.merchantService.Update(
+		ctx.Context(),
+		merchantId,
+		body,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[update merchant] failed updating merchant: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(merchantData)
+}
+
+func (h *MerchantHandler) Delete(
+	ctx *fiber.Ctx,
+) error {
+	merchantId, err := uuid.Parse(
+		ctx.Params("merchantId"),
+	)
+	if err != nil {
+		err = constant.ErrNotFound
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[delete merchant] failed to parse merchantId: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	err = h.merchantService.Delete(
+		ctx.Context(),
+		merchantId,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[delete merchant] failed deleting merchant: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"merchantId": merchantId.String(),
+	})
+}
+
+func (h *MerchantHandler) Restore(
+	ctx *fiber.Ctx,
+) error {
+	merchantId, err := uuid.Parse(
+		ctx.Params("merchantId"),
+	)
+	if err != nil {
+		err = constant.ErrNotFound
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[restore merchant] failed to parse merchantId: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	err = h.merchantService.Restore(
+		ctx.Context(),
+		merchantId,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[restore merchant] failed restoring merchant: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"merchantId": merchantId.String(),
+	})
+}
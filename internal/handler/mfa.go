@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
+)
+
+func (h *UserHandler) EnrolMFA(
+	ctx *fiber.Ctx,
+) error {
+	enrolment, err := h.userService.EnrolMFA(
+		ctx.Context(),
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[enrol mfa] failed to start enrolment: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.Status(fiber.StatusCreated).
+		JSON(enrolment.ToResponseBody())
+}
+
+func (h *UserHandler) EnrolPendingMFA(
+	ctx *fiber.Ctx,
+) error {
+	var body model.MFATokenRequestBody
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[enrol pending mfa] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	mfaToken, err := body.IsValid()
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[enrol pending mfa] failed to validate body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	enrolment, err := h.userService.EnrolPendingMFA(
+		ctx.Context(),
+		mfaToken,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[enrol pending mfa] failed to start enrolment: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.Status(fiber.StatusCreated).
+		JSON(enrolment.ToResponseBody())
+}
+
+func (h *UserHandler) ConfirmMFA(
+	ctx *fiber.Ctx,
+) error {
+	var body model.MFACodeRequestBody
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[confirm mfa] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	code, err := body.IsValid()
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[confirm mfa] failed to validate body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	recoveryCodes, err := h.userService.ConfirmMFA(
+		ctx.Context(),
+		code,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[confirm mfa] failed to confirm enrolment: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+func (h *UserHandler) VerifyMFA(
+	ctx *fiber.Ctx,
+) error {
+	var body model.MFAVerifyRequestBody
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[verify mfa] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	mfaToken, code, err := body.IsValid()
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[verify mfa] failed to validate body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	tokens, err := h.userService.VerifyMFA(
+		ctx.Context(),
+		mfaToken,
+		code,
+		ctx.IP(),
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[verify mfa] failed to authenticate: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(tokens.ToResponseBody())
+}
+
+func (h *UserHandler) FindMFASettings(
+	ctx *fiber.Ctx,
+) error {
+	settings, err := h.userService.FindMFASettings(
+		ctx.Context(),
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find mfa settings] failed finding settings: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(settings.ToResponseBody())
+}
+
+func (h *UserHandler) UpdateMFASettings(
+	ctx *fiber.Ctx,
+) error {
+	var body model.MFASettingsRequestBody
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[update mfa settings] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	settings, err := body.IsValid()
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[update mfa settings] failed to validate body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	settings, err = h.userService.UpdateMFASettings(
+		ctx.Context(),
+		settings,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[update mfa settings] failed updating settings: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(settings.ToResponseBody())
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/service"
+)
+
+type OrderHandler struct {
+	orderService *service.OrderService
+}
+
+func NewOrderHandler(
+	orderService *service.OrderService,
+) *OrderHandler {
+	return &OrderHandler{
+		orderService: orderService,
+	}
+}
+
+func (h *OrderHandler) Create(
+	ctx *fiber.Ctx,
+) error {
+	var body model.OrderRequestBody
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[create order] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	orderModel, err := body.IsValid()
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[create order] failed to validate body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	orderId, err := h.orderService.Create(
+		ctx.Context(),
+		orderModel,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[create order] failed creating order: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.Status(fiber.StatusCreated).
+		JSON(fiber.Map{
+			"orderId": orderId.String(),
+		})
+}
+
+func (h *OrderHandler) FindAll(
+	ctx *fiber.Ctx,
+) error {
+	var queries model.OrderQueries
+	ctx.QueryParser(&queries)
+	queries.Limit = ctx.QueryInt(
+		"limit",
+		5,
+	)
+	queries.Offset = ctx.QueryInt(
+		"offset",
+		0,
+	)
+	if err := queries.IsValid(); err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find order] invalid queries: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	orderData, total, err := h.orderService.FindAll(
+		ctx.Context(),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find order] failed to find orders: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data": orderData,
+		"meta": fiber.Map{
+			"limit":  queries.Limit,
+			"offset": queries.Offset,
+			"total":  total,
+		},
+	})
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -32,12 +33,11 @@ func (h *ProductHandler) Create(
 	var body model.ProductRequestBody
 	err := ctx.BodyParser(&body)
 	if err != nil {
-		err = constant.ErrBadInput
+		err = constant.ErrInvalidBody
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[create product] failed to parse body: %v",
 					err,
@@ -53,8 +53,7 @@ func (h *ProductHandler) Create(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[create product] failed to parse merchantId: %v",
 					err,
@@ -68,8 +67,7 @@ func (h *ProductHandler) Create(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[create product] failed to validate body: %v",
 					err,
@@ -87,8 +85,7 @@ func (h *ProductHandler) Create(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[create product] failed to create product: %v",
 					err,
@@ -116,10 +113,62 @@ func (h *ProductHandler) FindAll(
 		"offset",
 		0,
 	)
+	if err := queries.IsValid(); err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find products] invalid queries: %v",
+					err,
+				),
+			},
+		)
+	}
 	queries.CreatedAt = ctx.Query(
 		"createdAt",
 		"desc",
 	)
+	if err := queries.ParseSort(); err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find products] invalid sort: %v",
+					err,
+				),
+			},
+		)
+	}
+	queries.CursorQueries = model.CursorQueries{
+		Mode: model.PaginationMode(ctx.Query(
+			"pagination",
+			string(model.OffsetPagination),
+		)),
+		Cursor: ctx.Query("cursor"),
+		Total: model.TotalMode(ctx.Query(
+			"total",
+			string(model.TotalNone),
+		)),
+	}
+	if err := queries.ParseCursor(); err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find products] invalid cursor: %v",
+					err,
+				),
+			},
+		)
+	}
+	if isAvailable, err := strconv.ParseBool(
+		ctx.Query("isAvailable"),
+	); err == nil {
+		queries.IsAvailable = &isAvailable
+	}
 
 	merchantIdString := ctx.Params(
 		"merchantId",
@@ -131,8 +180,7 @@ func (h *ProductHandler) FindAll(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[find products] failed to find parse merchant id: %v",
 					err,
@@ -142,6 +190,27 @@ func (h *ProductHandler) FindAll(
 	}
 	queries.MerchantId = merchantId
 
+	if queries.IsCursor() {
+		productPage, err := h.productService.FindPage(
+			ctx.Context(),
+			queries,
+		)
+		if err != nil {
+			return HandleError(
+				ctx,
+				ErrorResponse{
+					error: err,
+					detail: fmt.Sprintf(
+						"[find products] failed to find products: %v",
+						err,
+					),
+				},
+			)
+		}
+
+		return ctx.JSON(productPage)
+	}
+
 	productResp, err := h.productService.FindAll(
 		ctx.Context(),
 		queries,
@@ -150,8 +219,7 @@ func (h *ProductHandler) FindAll(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[find products] failed to find products: %v",
 					err,
@@ -162,3 +230,138 @@ func (h *ProductHandler) FindAll(
 
 	return ctx.JSON(productResp)
 }
+
+func (h *ProductHandler) Update(
+	ctx *fiber.Ctx,
+) error {
+	merchantId, err := uuid.Parse(
+		ctx.Params("merchantId"),
+	)
+	if err != nil {
+		err = constant.ErrNotFound
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[update product] failed to parse merchantId: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	itemId, err := uuid.Parse(
+		ctx.Params("itemId"),
+	)
+	if err != nil {
+		err = constant.ErrNotFound
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[update product] failed to parse itemId: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	var body model.ProductPatchRequestBody
+	err = ctx.BodyParser(&body)
+	if err != nil || body.IsEmpty() {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[update product] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	productData, err := h.productService.Update(
+		ctx.Context(),
+		merchantId,
+		itemId,
+		body,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[update product] failed to update product: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(productData)
+}
+
+func (h *ProductHandler) Delete(
+	ctx *fiber.Ctx,
+) error {
+	merchantId, err := uuid.Parse(
+		ctx.Params("merchantId"),
+	)
+	if err != nil {
+		err = constant.ErrNotFound
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[delete product] failed to parse merchantId: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	itemId, err := uuid.Parse(
+		ctx.Params("itemId"),
+	)
+	if err != nil {
+		err = constant.ErrNotFound
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[delete product] failed to parse itemId: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	err = h.productService.Delete(
+		ctx.Context(),
+		merchantId,
+		itemId,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[delete product] failed to delete product: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"itemId": itemId.String(),
+	})
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/service"
+)
+
+type SearchHandler struct {
+	searchService *service.SearchService
+}
+
+func NewSearchHandler(
+	searchService *service.SearchService,
+) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+func (h *SearchHandler) Search(
+	ctx *fiber.Ctx,
+) error {
+	var queries model.SearchQueries
+	if err := ctx.QueryParser(&queries); err != nil {
+		err = constant.ErrBadInput
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[search] failed to parse queries: %v",
+					err,
+				),
+			},
+		)
+	}
+	queries.Limit = ctx.QueryInt(
+		"limit",
+		5,
+	)
+	queries.Offset = ctx.QueryInt(
+		"offset",
+		0,
+	)
+	if err := queries.IsValid(); err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[search] invalid queries: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	hitData, total, err := h.searchService.Search(
+		ctx.Context(),
+		queries,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[search] failed to search: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data": hitData,
+		"meta": fiber.Map{
+			"limit":  queries.Limit,
+			"offset": queries.Offset,
+			"total":  total,
+		},
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/belimang/internal/constant"
 	"github.com/nozzlium/belimang/internal/model"
 	"github.com/nozzlium/belimang/internal/service"
 )
@@ -29,8 +30,7 @@ func (h *UserHandler) RegisterAdmin(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: constant.ErrInvalidBody,
 				detail: fmt.Sprintf(
 					"[register admin] failed to parse body: %v",
 					err,
@@ -44,8 +44,7 @@ func (h *UserHandler) RegisterAdmin(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[register admin] failed to validate body: %v",
 					err,
@@ -54,7 +53,7 @@ func (h *UserHandler) RegisterAdmin(
 		)
 	}
 
-	token, err := h.userService.RegisterAdmin(
+	tokens, err := h.userService.RegisterAdmin(
 		ctx.Context(),
 		userModel,
 	)
@@ -62,8 +61,7 @@ func (h *UserHandler) RegisterAdmin(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[register admin] failed to store user: %v",
 					err,
@@ -73,9 +71,7 @@ func (h *UserHandler) RegisterAdmin(
 	}
 
 	return ctx.Status(fiber.StatusCreated).
-		JSON(fiber.Map{
-			"token": token,
-		})
+		JSON(tokens.ToResponseBody())
 }
 
 func (h *UserHandler) LoginAdmin(
@@ -87,8 +83,7 @@ func (h *UserHandler) LoginAdmin(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: constant.ErrInvalidBody,
 				detail: fmt.Sprintf(
 					"[login admin] failed to parse body: %v",
 					err,
@@ -102,8 +97,7 @@ func (h *UserHandler) LoginAdmin(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[login admin] failed to validate body: %v",
 					err,
@@ -112,16 +106,16 @@ func (h *UserHandler) LoginAdmin(
 		)
 	}
 
-	token, err := h.userService.LoginAdmin(
+	tokens, err := h.userService.LoginAdmin(
 		ctx.Context(),
 		userModel,
+		ctx.IP(),
 	)
 	if err != nil {
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[login admin] failed to authenticate: %v",
 					err,
@@ -130,9 +124,7 @@ func (h *UserHandler) LoginAdmin(
 		)
 	}
 
-	return ctx.JSON(fiber.Map{
-		"token": token,
-	})
+	return ctx.JSON(tokens.ToResponseBody())
 }
 
 func (h *UserHandler) RegisterUser(
@@ -144,8 +136,7 @@ func (h *UserHandler) RegisterUser(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: constant.ErrInvalidBody,
 				detail: fmt.Sprintf(
 					"[register user] failed to parse body: %v",
 					err,
@@ -159,8 +150,7 @@ func (h *UserHandler) RegisterUser(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[register user] failed to validate body: %v",
 					err,
@@ -169,7 +159,7 @@ func (h *UserHandler) RegisterUser(
 		)
 	}
 
-	token, err := h.userService.RegisterUser(
+	tokens, err := h.userService.RegisterUser(
 		ctx.Context(),
 		userModel,
 	)
@@ -177,8 +167,7 @@ func (h *UserHandler) RegisterUser(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[register user] failed to store user: %v",
 					err,
@@ -188,9 +177,7 @@ func (h *UserHandler) RegisterUser(
 	}
 
 	return ctx.Status(fiber.StatusCreated).
-		JSON(fiber.Map{
-			"token": token,
-		})
+		JSON(tokens.ToResponseBody())
 }
 
 func (h *UserHandler) LoginUser(
@@ -202,8 +189,7 @@ func (h *UserHandler) LoginUser(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: constant.ErrInvalidBody,
 				detail: fmt.Sprintf(
 					"[login user] failed to parse body: %v",
 					err,
@@ -217,8 +203,7 @@ func (h *UserHandler) LoginUser(
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[login user] failed to validate body: %v",
 					err,
@@ -227,16 +212,16 @@ func (h *UserHandler) LoginUser(
 		)
 	}
 
-	token, err := h.userService.LoginUser(
+	tokens, err := h.userService.LoginUser(
 		ctx.Context(),
 		userModel,
+		ctx.IP(),
 	)
 	if err != nil {
 		return HandleError(
 			ctx,
 			ErrorResponse{
-				error:   err,
-				message: err.Error(),
+				error: err,
 				detail: fmt.Sprintf(
 					"[login user] failed to authenticate: %v",
 					err,
@@ -245,7 +230,431 @@ func (h *UserHandler) LoginUser(
 		)
 	}
 
-	return ctx.JSON(fiber.Map{
-		"token": token,
-	})
+	return ctx.JSON(tokens.ToResponseBody())
+}
+
+func (h *UserHandler) Refresh(
+	ctx *fiber.Ctx,
+) error {
+	var body model.RefreshRequestBody
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[refresh token] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	refreshToken, err := body.IsValid()
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[refresh token] failed to validate body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	tokens, err := h.userService.Refresh(
+		ctx.Context(),
+		refreshToken,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[refresh token] failed to refresh token: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(tokens.ToResponseBody())
+}
+
+func (h *UserHandler) Logout(
+	ctx *fiber.Ctx,
+) error {
+	err := h.userService.Logout(
+		ctx.Context(),
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[logout] failed to revoke tokens: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UserHandler) LogoutAll(
+	ctx *fiber.Ctx,
+) error {
+	err := h.userService.LogoutAll(
+		ctx.Context(),
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[logout all] failed to revoke tokens: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UserHandler) JWKS(
+	ctx *fiber.Ctx,
+) error {
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return ctx.Status(fiber.StatusOK).
+		JSON(h.userService.JWKS())
+}
+
+func (h *UserHandler) Unlock(
+	ctx *fiber.Ctx,
+) error {
+	username := ctx.Params("username")
+	err := h.userService.Unlock(
+		ctx.Context(),
+		username,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[unlock user] failed unlocking %s: %v",
+					username,
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UserHandler) VerifyEmail(
+	ctx *fiber.Ctx,
+) error {
+	var body model.VerifyEmailRequestBody
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[verify email] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	token, err := body.IsValid()
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[verify email] failed to validate body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	err = h.userService.VerifyEmail(
+		ctx.Context(),
+		token,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[verify email] failed to verify email: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UserHandler) ResendVerificationEmail(
+	ctx *fiber.Ctx,
+) error {
+	err := h.userService.ResendVerificationEmail(
+		ctx.Context(),
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[resend verification email] failed to send email: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.SendStatus(fiber.StatusAccepted)
+}
+
+func (h *UserHandler) ForgotPassword(
+	ctx *fiber.Ctx,
+) error {
+	var body model.ForgotPasswordRequestBody
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[forgot password] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	email, err := body.IsValid()
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[forgot password] failed to validate body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	err = h.userService.ForgotPassword(
+		ctx.Context(),
+		email,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[forgot password] failed to send reset email: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.SendStatus(fiber.StatusAccepted)
+}
+
+func (h *UserHandler) ResetPassword(
+	ctx *fiber.Ctx,
+) error {
+	var body model.ResetPasswordRequestBody
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[reset password] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	token, password, err := body.IsValid()
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[reset password] failed to validate body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	err = h.userService.ResetPassword(
+		ctx.Context(),
+		token,
+		password,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[reset password] failed to reset password: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UserHandler) FindMe(
+	ctx *fiber.Ctx,
+) error {
+	user, err := h.userService.FindMe(
+		ctx.Context(),
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[find me] failed finding account: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(user)
+}
+
+func (h *UserHandler) UpdateMe(
+	ctx *fiber.Ctx,
+) error {
+	var body model.UserPatchRequestBody
+	err := ctx.BodyParser(&body)
+	if err != nil || body.IsEmpty() {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[update me] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	user, err := h.userService.UpdateMe(
+		ctx.Context(),
+		body,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[update me] failed updating account: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(user)
+}
+
+func (h *UserHandler) AddRole(
+	ctx *fiber.Ctx,
+) error {
+	var body model.RoleRequestBody
+	err := ctx.BodyParser(&body)
+	if err != nil {
+		err = constant.ErrInvalidBody
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[add role] failed to parse body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	role, err := body.IsValid()
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[add role] failed to validate body: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	user, err := h.userService.AddRole(
+		ctx.Context(),
+		role,
+	)
+	if err != nil {
+		return HandleError(
+			ctx,
+			ErrorResponse{
+				error: err,
+				detail: fmt.Sprintf(
+					"[add role] failed adding role: %v",
+					err,
+				),
+			},
+		)
+	}
+
+	return ctx.JSON(user)
 }
@@ -1,88 +1,155 @@
 package middleware
 
 import (
-	"os"
+	"context"
+	"fmt"
+	"time"
 
 	jwtware "github.com/gofiber/contrib/jwt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/segmentio/asm/base64"
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/config"
+	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
 )
 
+// RevocationChecker reports whether an otherwise valid access token has
+// been revoked through logout.
+type RevocationChecker interface {
+	IsRevoked(
+		ctx context.Context,
+		tokenID uuid.UUID,
+		userID uuid.UUID,
+		issuedAt time.Time,
+	) (bool, error)
+}
+
 // Protected protect routes
-func Protected() func(*fiber.Ctx) error {
-	secret := os.Getenv("JWT_SECRET")
+func Protected(
+	authConfig config.AuthConfig,
+	revocationChecker RevocationChecker,
+) func(*fiber.Ctx) error {
+	jwtConfig := jwtware.Config{
+		ErrorHandler:   jwtError,
+		SuccessHandler: authenticate(authConfig, revocationChecker),
+		ContextKey:     "userData",
+		Claims:         &model.AccessTokenClaims{},
+	}
+
+	// in asymmetric mode every active key is registered under its kid, so
+	// tokens signed before a key rotation keep verifying.
+	if len(authConfig.VerificationKeys) == 0 {
+		jwtConfig.SigningKey = jwtware.SigningKey{
+			JWTAlg: jwtware.HS256,
+			Key:    authConfig.JWTSecret,
+		}
+	} else {
+		jwtConfig.SigningKeys = make(
+			map[string]jwtware.SigningKey,
+			len(authConfig.VerificationKeys),
+		)
+		for kid, verificationKey := range authConfig.VerificationKeys {
+			jwtConfig.SigningKeys[kid] = jwtware.SigningKey{
+				JWTAlg: verificationKey.Method.Alg(),
+				Key:    verificationKey.Key,
+			}
+		}
+	}
 
-	return jwtware.New(jwtware.Config{
-		SigningKey: jwtware.SigningKey{
-			Key: []byte(secret),
-		},
-		ErrorHandler: jwtError,
-		ContextKey:   "userData",
-	})
+	return jwtware.New(jwtConfig)
 }
 
-func SetClaimsData() func(*fiber.Ctx) error {
+// authenticate checks the claims jwtware cannot (issuer, audience) and the
+// revocation lists, then stores the caller's model.Principal.
+func authenticate(
+	authConfig config.AuthConfig,
+	revocationChecker RevocationChecker,
+) func(*fiber.Ctx) error {
+	validator := jwt.NewValidator(
+		jwt.WithIssuer(authConfig.Issuer),
+		jwt.WithAudience(authConfig.Audience),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+
 	return func(c *fiber.Ctx) error {
-		user := c.Locals("userData").(*jwt.Token).Claims.(jwt.MapClaims)
-		emailByte, err := base64.RawStdEncoding.DecodeString(
-			user["mm"].(string),
-		)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).
-				JSON(fiber.Map{"message": "invalid token"})
+		token := c.Locals("userData").(*jwt.Token)
+		claims, ok := token.Claims.(*model.AccessTokenClaims)
+		if !ok {
+			return fmt.Errorf(
+				"%w: unexpected claims type %T",
+				constant.ErrUnauthorized,
+				token.Claims,
+			)
 		}
-		c.Locals(
-			"email",
-			string(emailByte),
-		)
-
-		usernameByte, err := base64.RawStdEncoding.DecodeString(
-			user["rn"].(string),
-		)
+		if err := validator.Validate(claims); err != nil {
+			return fmt.Errorf(
+				"%w: %v",
+				constant.ErrUnauthorized,
+				err,
+			)
+		}
+		principal, err := claims.Principal()
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).
-				JSON(fiber.Map{"message": "invalid token"})
+			return fmt.Errorf(
+				"%w: %v",
+				constant.ErrUnauthorized,
+				err,
+			)
 		}
-		c.Locals(
-			"username",
-			string(usernameByte),
-		)
 
-		userIDByte, err := base64.RawStdEncoding.DecodeString(
-			user["si"].(string),
+		revoked, err := revocationChecker.IsRevoked(
+			c.Context(),
+			principal.TokenID,
+			principal.UserID,
+			claims.IssuedAt.Time,
 		)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).
-				JSON(fiber.Map{"message": "invalid token"})
+			return fmt.Errorf(
+				"failed to check token revocation: %w",
+				err,
+			)
+		}
+		if revoked {
+			return constant.ErrTokenRevoked
 		}
+
 		c.Locals(
-			"userID",
-			string(userIDByte),
+			model.PrincipalContextKey,
+			principal,
 		)
 
 		return c.Next()
 	}
 }
 
+// RequireRole only lets requests through when the caller holds at least
+// one of the given roles. It must be mounted after Protected.
+func RequireRole(
+	roles ...model.Role,
+) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		principal, _ := c.Locals(model.PrincipalContextKey).(model.Principal)
+		for _, allowed := range roles {
+			if principal.HasRole(allowed) {
+				return c.Next()
+			}
+		}
+
+		return constant.ErrForbidden
+	}
+}
+
+// jwtError leaves rendering to the app's error handler, like every other
+// middleware.
 func jwtError(
 	c *fiber.Ctx,
 	err error,
 ) error {
-	if err.Error() == "Missing or malformed JWT" {
-		c.Status(
-			fiber.StatusUnauthorized,
-		)
-		return c.JSON(
-			fiber.Map{
-				"status":  "error",
-				"message": "Missing or malformed JWT",
-				"data":    nil,
-			},
-		)
-
-	} else {
-		c.Status(fiber.StatusUnauthorized)
-		return c.JSON(fiber.Map{"status": "error", "message": "Invalid or expired JWT", "data": nil})
-	}
+	return fmt.Errorf(
+		"%w: %v",
+		constant.ErrUnauthorized,
+		err,
+	)
 }
@@ -0,0 +1,157 @@
+package middleware_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/config"
+	"github.com/nozzlium/belimang/internal/handler"
+	"github.com/nozzlium/belimang/internal/middleware"
+	"github.com/nozzlium/belimang/internal/model"
+)
+
+var testAuthConfig = config.AuthConfig{
+	SigningMethod:  jwt.SigningMethodHS256,
+	SigningKey:     []byte("test-secret-test-secret-test-secret"),
+	JWTSecret:      []byte("test-secret-test-secret-test-secret"),
+	AccessTokenTTL: time.Minute,
+	Issuer:         "belimang",
+	Audience:       "belimang",
+}
+
+type notRevoked struct{}
+
+func (notRevoked) IsRevoked(
+	ctx context.Context,
+	tokenID uuid.UUID,
+	userID uuid.UUID,
+	issuedAt time.Time,
+) (bool, error) {
+	return false, nil
+}
+
+// newTestApp answers 200 on /protected to every request Protected lets
+// through. The routes of the api are tested in the router package.
+func newTestApp(authConfig config.AuthConfig) *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: handler.ErrorHandler,
+	})
+	app.Get(
+		"/protected",
+		middleware.Protected(authConfig, notRevoked{}),
+		func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		},
+	)
+
+	return app
+}
+
+func accessClaims(roles ...model.Role) model.AccessTokenClaims {
+	now := time.Now()
+	claims := model.NewAccessTokenClaims(
+		model.User{
+			ID:       uuid.New(),
+			Username: "tester",
+			Email:    "tester@example.com",
+			Roles:    roles,
+		},
+		uuid.New(),
+		uuid.New(),
+	)
+	claims.Issuer = testAuthConfig.Issuer
+	claims.Audience = jwt.ClaimStrings{testAuthConfig.Audience}
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(time.Minute))
+
+	return claims
+}
+
+func signToken(
+	t *testing.T,
+	method jwt.SigningMethod,
+	key interface{},
+	claims jwt.Claims,
+) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func requestStatus(
+	t *testing.T,
+	app *fiber.App,
+	path string,
+	authorization string,
+) int {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodGet, path, nil)
+	if authorization != "" {
+		req.Header.Set(fiber.HeaderAuthorization, authorization)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request to %s failed: %v", path, err)
+	}
+	return resp.StatusCode
+}
+
+func TestProtectedRejectsTokensNotSignedWithTheKey(t *testing.T) {
+	app := newTestApp(testAuthConfig)
+	claims := accessClaims(model.RoleUser)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{
+			"signed with the secret",
+			signToken(t, jwt.SigningMethodHS256, testAuthConfig.SigningKey, claims),
+			fiber.StatusOK,
+		},
+		{
+			"signed with a different secret",
+			signToken(t, jwt.SigningMethodHS256, []byte("another-secret-another-secret-12"), claims),
+			fiber.StatusUnauthorized,
+		},
+		{
+			"unsigned with alg none",
+			signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims),
+			fiber.StatusUnauthorized,
+		},
+		{
+			"signed with the secret under HS512",
+			signToken(t, jwt.SigningMethodHS512, testAuthConfig.SigningKey, claims),
+			fiber.StatusUnauthorized,
+		},
+		{
+			"signed with an EdDSA key",
+			signToken(t, jwt.SigningMethodEdDSA, edKey, claims),
+			fiber.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := requestStatus(t, app, "/protected", "Bearer "+tt.token)
+			if got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,406 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nozzlium/belimang/internal/util"
+)
+
+// lockKey is the pg_advisory_lock key held while migrating, so concurrent
+// processes (prefork children, several instances) apply migrations one at
+// a time.
+const lockKey int64 = 0x62656c696d616e67
+
+var ErrDirty = errors.New(
+	"database is in a dirty migration state, fix it manually before migrating",
+)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Migration
+	Applied bool
+}
+
+// Migrator applies golang-migrate style "<version>_<name>.(up|down).sql"
+// files and records progress in the same schema_migrations table, so
+// databases migrated with the golang-migrate CLI keep working.
+type Migrator struct {
+	db         *pgxpool.Pool
+	migrations []Migration
+}
+
+func New(
+	db *pgxpool.Pool,
+	source fs.FS,
+) (*Migrator, error) {
+	migrations, err := load(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Up applies every pending migration and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		version, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			err := apply(
+				ctx,
+				conn,
+				migration.Up,
+				migration.Version,
+			)
+			if err != nil {
+				return fmt.Errorf(
+					"migration %d_%s up: %w",
+					migration.Version,
+					migration.Name,
+					err,
+				)
+			}
+			applied++
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts up to steps of the most recently applied migrations and
+// returns how many were reverted.
+func (m *Migrator) Down(
+	ctx context.Context,
+	steps int,
+) (int, error) {
+	reverted := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		version, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+
+			var previous int64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			err := apply(
+				ctx,
+				conn,
+				migration.Down,
+				previous,
+			)
+			if err != nil {
+				return fmt.Errorf(
+					"migration %d_%s down: %w",
+					migration.Version,
+					migration.Name,
+					err,
+				)
+			}
+			version = previous
+			reverted++
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(
+	ctx context.Context,
+) ([]Status, bool, error) {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer conn.Release()
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return nil, false, err
+	}
+
+	var version int64
+	var dirty bool
+	err = conn.QueryRow(
+		ctx,
+		"select version, dirty from schema_migrations limit 1",
+	).Scan(&version, &dirty)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, err
+	}
+
+	statuses := make(
+		[]Status,
+		0,
+		len(m.migrations),
+	)
+	for _, migration := range m.migrations {
+		statuses = append(
+			statuses,
+			Status{
+				Migration: migration,
+				Applied:   migration.Version <= version,
+			},
+		)
+	}
+
+	return statuses, dirty, nil
+}
+
+// Create writes an empty up/down pair named after the current Jakarta time
+// into dir and returns their paths.
+func Create(
+	dir string,
+	name string,
+) (string, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "", errors.New("migration name is required")
+	}
+
+	prefix := fmt.Sprintf(
+		"%s_%s",
+		util.Now().Format("20060102150405"),
+		strings.ReplaceAll(name, " ", "_"),
+	)
+	upPath := filepath.Join(dir, prefix+".up.sql")
+	downPath := filepath.Join(dir, prefix+".down.sql")
+	for _, path := range []string{upPath, downPath} {
+		file, err := os.OpenFile(
+			path,
+			os.O_CREATE|os.O_EXCL|os.O_WRONLY,
+			0o644,
+		)
+		if err != nil {
+			return "", "", err
+		}
+		if err := file.Close(); err != nil {
+			return "", "", err
+		}
+	}
+
+	return upPath, downPath, nil
+}
+
+func (m *Migrator) withLock(
+	ctx context.Context,
+	fn func(conn *pgxpool.Conn) error,
+) error {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	// advisory locks are held by the session, so the lock, the migrations
+	// and the unlock all have to run on this one connection.
+	_, err = conn.Exec(
+		ctx,
+		"select pg_advisory_lock($1)",
+		lockKey,
+	)
+	if err != nil {
+		return err
+	}
+	defer conn.Exec(
+		context.Background(),
+		"select pg_advisory_unlock($1)",
+		lockKey,
+	)
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func ensureVersionTable(
+	ctx context.Context,
+	conn *pgxpool.Conn,
+) error {
+	_, err := conn.Exec(
+		ctx,
+		`create table if not exists schema_migrations (
+      version bigint not null primary key,
+      dirty boolean not null
+    )`,
+	)
+
+	return err
+}
+
+func currentVersion(
+	ctx context.Context,
+	conn *pgxpool.Conn,
+) (int64, error) {
+	var version int64
+	var dirty bool
+	err := conn.QueryRow(
+		ctx,
+		"select version, dirty from schema_migrations limit 1",
+	).Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if dirty {
+		return 0, ErrDirty
+	}
+
+	return version, nil
+}
+
+// apply runs one migration file and records version as the current one in
+// the same transaction, so a failing file leaves no partial state behind.
+func apply(
+	ctx context.Context,
+	conn *pgxpool.Conn,
+	sql string,
+	version int64,
+) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if strings.TrimSpace(sql) != "" {
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, "truncate schema_migrations"); err != nil {
+		return err
+	}
+	if version > 0 {
+		_, err := tx.Exec(
+			ctx,
+			"insert into schema_migrations (version, dirty) values ($1, false)",
+			version,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func load(source fs.FS) ([]Migration, error) {
+	byVersion := make(map[int64]*Migration)
+	err := fs.WalkDir(
+		source,
+		".",
+		func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			base := filepath.Base(path)
+			var direction string
+			switch {
+			case strings.HasSuffix(base, ".up.sql"):
+				direction = "up"
+			case strings.HasSuffix(base, ".down.sql"):
+				direction = "down"
+			default:
+				return nil
+			}
+
+			versionString, name, found := strings.Cut(
+				strings.TrimSuffix(base, "."+direction+".sql"),
+				"_",
+			)
+			if !found {
+				return fmt.Errorf("invalid migration file name %q", base)
+			}
+			version, err := strconv.ParseInt(versionString, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid migration version in %q: %w", base, err)
+			}
+
+			content, err := fs.ReadFile(source, path)
+			if err != nil {
+				return err
+			}
+
+			migration, ok := byVersion[version]
+			if !ok {
+				migration = &Migration{
+					Version: version,
+					Name:    name,
+				}
+				byVersion[version] = migration
+			}
+			if direction == "up" {
+				migration.Up = string(content)
+			} else {
+				migration.Down = string(content)
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make(
+		[]Migration,
+		0,
+		len(byVersion),
+	)
+	for _, migration := range byVersion {
+		migrations = append(
+			migrations,
+			*migration,
+		)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
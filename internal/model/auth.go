@@ -0,0 +1,168 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/constant"
+)
+
+type contextKey string
+
+// PrincipalContextKey is the key the auth middleware stores the caller's
+// Principal under.
+const PrincipalContextKey contextKey = "principal"
+
+const (
+	ScopeMerchantsRead  = "merchants:read"
+	ScopeMerchantsWrite = "merchants:write"
+	ScopeOrdersRead     = "orders:read"
+	ScopeOrdersWrite    = "orders:write"
+)
+
+var roleScopes = map[Role][]string{
+	RoleAdmin: {
+		ScopeMerchantsRead,
+		ScopeMerchantsWrite,
+	},
+	RoleUser: {
+		ScopeMerchantsRead,
+		ScopeOrdersRead,
+		ScopeOrdersWrite,
+	},
+	// operators are admins as well and get their scopes from that role
+	RoleOperator: {},
+}
+
+func (r Role) IsValid() bool {
+	_, ok := roleScopes[r]
+	return ok
+}
+
+// ScopesOf returns the union of the scopes granted to the roles.
+func ScopesOf(roles []Role) []string {
+	scopes := make([]string, 0, len(roleScopes)*2)
+	seen := make(map[string]bool)
+	for _, role := range roles {
+		for _, scope := range roleScopes[role] {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	return scopes
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID    uuid.UUID
+	Email     string
+	Username  string
+	Roles     []Role
+	Scopes    []string
+	SessionID uuid.UUID
+	TokenID   uuid.UUID
+	ExpiresAt time.Time
+}
+
+func (p Principal) HasRole(role Role) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// PrincipalFromContext returns constant.ErrUnauthorized when the request
+// did not go through the auth middleware.
+func PrincipalFromContext(
+	ctx context.Context,
+) (Principal, error) {
+	principal, ok := ctx.Value(PrincipalContextKey).(Principal)
+	if !ok {
+		return Principal{}, constant.ErrUnauthorized
+	}
+
+	return principal, nil
+}
+
+// AccessTokenClaims is the payload of an access token. Scope holds space
+// separated scopes as in RFC 8693.
+type AccessTokenClaims struct {
+	jwt.RegisteredClaims
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	Roles     []Role `json:"roles"`
+	Scope     string `json:"scope,omitempty"`
+	SessionID string `json:"sid"`
+}
+
+func NewAccessTokenClaims(
+	user User,
+	sessionID uuid.UUID,
+	tokenID uuid.UUID,
+) AccessTokenClaims {
+	return AccessTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:      tokenID.String(),
+			Subject: user.ID.String(),
+		},
+		Email:     user.Email,
+		Username:  user.Username,
+		Roles:     user.Roles,
+		Scope:     strings.Join(ScopesOf(user.Roles), " "),
+		SessionID: sessionID.String(),
+	}
+}
+
+// Validate is called by the jwt parser once the registered claims have
+// been checked, so a token missing any identity claim is rejected as a
+// whole.
+func (c AccessTokenClaims) Validate() error {
+	_, err := c.Principal()
+	return err
+}
+
+func (c AccessTokenClaims) Principal() (Principal, error) {
+	userID, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return Principal{}, errors.New("sub is not a user id")
+	}
+	sessionID, err := uuid.Parse(c.SessionID)
+	if err != nil {
+		return Principal{}, errors.New("sid is not a session id")
+	}
+	tokenID, err := uuid.Parse(c.ID)
+	if err != nil {
+		return Principal{}, errors.New("jti is not a token id")
+	}
+	if len(c.Roles) == 0 {
+		return Principal{}, errors.New("roles are required")
+	}
+	for _, role := range c.Roles {
+		if !role.IsValid() {
+			return Principal{}, errors.New("role is unknown")
+		}
+	}
+	if c.IssuedAt == nil {
+		return Principal{}, errors.New("iat is required")
+	}
+
+	principal := Principal{
+		UserID:    userID,
+		Email:     c.Email,
+		Username:  c.Username,
+		Roles:     c.Roles,
+		Scopes:    strings.Fields(c.Scope),
+		SessionID: sessionID,
+		TokenID:   tokenID,
+	}
+	if c.ExpiresAt != nil {
+		principal.ExpiresAt = c.ExpiresAt.Time
+	}
+
+	return principal, nil
+}
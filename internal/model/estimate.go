@@ -0,0 +1,213 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/validation"
+)
+
+type Estimate struct {
+	ID                             uuid.UUID
+	UserID                         uuid.UUID
+	UserLatitude                   float64
+	UserLongitude                  float64
+	TotalPrice                     float64
+	EstimatedDeliveryTimeInMinutes int
+	Orders                         []EstimateOrder
+	CreatedAt                      time.Time
+}
+
+type EstimateOrder struct {
+	MerchantID      uuid.UUID
+	IsStartingPoint bool
+	Items           []EstimateItem
+}
+
+type EstimateItem struct {
+	ProductID uuid.UUID
+	Quantity  int
+	Price     float64
+}
+
+type EstimateRequestBody struct {
+	UserLocation MerchantLocationRequestBody `json:"userLocation"`
+	Orders       []EstimateOrderRequestBody  `json:"orders"`
+}
+
+type EstimateOrderRequestBody struct {
+	MerchantID      string                    `json:"merchantId"`
+	IsStartingPoint bool                      `json:"isStartingPoint"`
+	Items           []EstimateItemRequestBody `json:"items"`
+}
+
+type EstimateItemRequestBody struct {
+	ItemID   string `json:"itemId"`
+	Quantity int    `json:"quantity"`
+}
+
+func (body EstimateRequestBody) IsValid() (Estimate, error) {
+	v := validation.New()
+	v.Field(
+		"userLocation.lat",
+		validation.Latitude(body.UserLocation.Lat),
+	)
+	v.Field(
+		"userLocation.long",
+		validation.Longitude(body.UserLocation.Long),
+	)
+	v.Field(
+		"orders",
+		validation.NotEmpty(body.Orders),
+	)
+
+	startingPoints := 0
+	seenMerchants := make(
+		map[uuid.UUID]struct{},
+		len(body.Orders),
+	)
+	seenItems := make(map[uuid.UUID]struct{})
+	orders := make(
+		[]EstimateOrder,
+		0,
+		len(body.Orders),
+	)
+	for i, orderBody := range body.Orders {
+		orderField := fmt.Sprintf("orders[%d]", i)
+
+		merchantID, err := uuid.Parse(
+			orderBody.MerchantID,
+		)
+		if err != nil {
+			v.Field(
+				orderField+".merchantId",
+				validation.UUID(orderBody.MerchantID),
+			)
+		} else if _, ok := seenMerchants[merchantID]; ok {
+			v.Add(
+				orderField+".merchantId",
+				"duplicate",
+				"must not repeat another order's merchant",
+			)
+		} else {
+			seenMerchants[merchantID] = struct{}{}
+		}
+
+		if orderBody.IsStartingPoint {
+			startingPoints++
+		}
+
+		v.Field(
+			orderField+".items",
+			validation.NotEmpty(orderBody.Items),
+		)
+		items := make(
+			[]EstimateItem,
+			0,
+			len(orderBody.Items),
+		)
+		for j, itemBody := range orderBody.Items {
+			itemField := fmt.Sprintf("%s.items[%d]", orderField, j)
+
+			itemID, err := uuid.Parse(
+				itemBody.ItemID,
+			)
+			if err != nil {
+				v.Field(
+					itemField+".itemId",
+					validation.UUID(itemBody.ItemID),
+				)
+			} else if _, ok := seenItems[itemID]; ok {
+				v.Add(
+					itemField+".itemId",
+					"duplicate",
+					"must not repeat another item",
+				)
+			} else {
+				seenItems[itemID] = struct{}{}
+			}
+
+			v.Field(
+				itemField+".quantity",
+				validation.Min(itemBody.Quantity, 1),
+			)
+			items = append(
+				items,
+				EstimateItem{
+					ProductID: itemID,
+					Quantity:  itemBody.Quantity,
+				},
+			)
+		}
+
+		orders = append(
+			orders,
+			EstimateOrder{
+				MerchantID:      merchantID,
+				IsStartingPoint: orderBody.IsStartingPoint,
+				Items:           items,
+			},
+		)
+	}
+	if startingPoints > 1 {
+		v.Add(
+			"orders",
+			"starting_point",
+			"must have at most one starting point",
+		)
+	}
+	if err := v.Err(); err != nil {
+		return Estimate{}, err
+	}
+
+	return Estimate{
+		UserLatitude:  body.UserLocation.Lat,
+		UserLongitude: body.UserLocation.Long,
+		Orders:        orders,
+	}, nil
+}
+
+func (e *Estimate) MerchantIDs() []uuid.UUID {
+	merchantIDs := make(
+		[]uuid.UUID,
+		0,
+		len(e.Orders),
+	)
+	for _, order := range e.Orders {
+		merchantIDs = append(
+			merchantIDs,
+			order.MerchantID,
+		)
+	}
+
+	return merchantIDs
+}
+
+func (e *Estimate) ProductIDs() []uuid.UUID {
+	var productIDs []uuid.UUID
+	for _, order := range e.Orders {
+		for _, item := range order.Items {
+			productIDs = append(
+				productIDs,
+				item.ProductID,
+			)
+		}
+	}
+
+	return productIDs
+}
+
+type EstimateResponseBody struct {
+	TotalPrice                     float64 `json:"totalPrice"`
+	EstimatedDeliveryTimeInMinutes int     `json:"estimatedDeliveryTimeInMinutes"`
+	CalculatedEstimateID           string  `json:"calculatedEstimateId"`
+}
+
+func (e *Estimate) ToResponseBody() EstimateResponseBody {
+	return EstimateResponseBody{
+		TotalPrice:                     e.TotalPrice,
+		EstimatedDeliveryTimeInMinutes: e.EstimatedDeliveryTimeInMinutes,
+		CalculatedEstimateID:           e.ID.String(),
+	}
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/constant"
+)
+
+type LockoutScope string
+
+const (
+	LockoutScopeUsername LockoutScope = "username"
+	LockoutScopeIP       LockoutScope = "ip"
+)
+
+type LoginAttempt struct {
+	ID        uuid.UUID
+	Username  string
+	IPAddress string
+	Succeeded bool
+	CreatedAt time.Time
+}
+
+// LockoutError is returned while the username or the client ip of a login
+// is locked out.
+type LockoutError struct {
+	LockedUntil time.Time
+}
+
+func (e LockoutError) Error() string {
+	return constant.ErrTooManyAttempts.Error()
+}
+
+func (e LockoutError) Unwrap() error {
+	return constant.ErrTooManyAttempts
+}
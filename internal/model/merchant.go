@@ -1,12 +1,15 @@
 package model
 
 import (
-	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/sqlbuilder"
 	"github.com/nozzlium/belimang/internal/util"
+	"github.com/nozzlium/belimang/internal/validation"
 )
 
 type (
@@ -46,6 +49,19 @@ type Merchant struct {
 	Latitude         float64
 	Longitude        float64
 	CreatedAt        time.Time
+	DeletedAt        *time.Time
+}
+
+// CursorValue returns the value of a sorted column as text for a cursor.
+func (m Merchant) CursorValue(column string) string {
+	switch column {
+	case "name":
+		return m.Name
+	case "created_at":
+		return m.CreatedAt.Format(sqlbuilder.TimestampLayout)
+	default:
+		return m.ID.String()
+	}
 }
 
 type MerchantRequestBody struct {
@@ -60,98 +76,191 @@ type MerchantLocationRequestBody struct {
 	Long float64 `json:"long"`
 }
 
-func (body MerchantRequestBody) IsValid() (Merchant, error) {
-	var merchant Merchant
-	if nameLen := len(body.Name); nameLen < 2 ||
-		nameLen > 30 {
-		return merchant, constant.ErrBadInput
-	}
-	merchant.Name = body.Name
-
-	switch body.MerchantCategory {
-	case SmallRestaurant,
-		MediumRestaurant,
-		LargeRestaurant,
-		MerchandiseRestaurant,
-		BoothKiosk,
-		ConvenienceStore:
-		merchant.MerchantCategory = body.MerchantCategory
-	default:
-		return merchant, constant.ErrBadInput
-	}
+var merchantCategories = []MerchantCategory{
+	SmallRestaurant,
+	MediumRestaurant,
+	LargeRestaurant,
+	MerchandiseRestaurant,
+	BoothKiosk,
+	ConvenienceStore,
+}
 
-	err := util.ValidateURL(
-		body.ImageURL,
+func (body MerchantRequestBody) IsValid() (Merchant, error) {
+	v := validation.New()
+	v.Field(
+		"name",
+		validation.Length(body.Name, 2, 30),
 	)
-	if err != nil {
+	v.Field(
+		"merchantCategory",
+		validation.OneOf(body.MerchantCategory, merchantCategories...),
+	)
+	v.Field(
+		"imageUrl",
+		validation.URL(body.ImageURL),
+	)
+	v.Field(
+		"location.lat",
+		validation.Latitude(body.Location.Lat),
+	)
+	v.Field(
+		"location.long",
+		validation.Longitude(body.Location.Long),
+	)
+	if err := v.Err(); err != nil {
 		return Merchant{}, err
 	}
-	merchant.ImageURL = body.ImageURL
 
-	merchant.Latitude = body.Location.Lat
-	merchant.Longitude = body.Location.Long
+	return Merchant{
+		Name:             body.Name,
+		MerchantCategory: body.MerchantCategory,
+		ImageURL:         body.ImageURL,
+		Latitude:         body.Location.Lat,
+		Longitude:        body.Location.Long,
+	}, nil
+}
+
+type MerchantPatchRequestBody struct {
+	Name             *string                      `json:"name"`
+	MerchantCategory *MerchantCategory            `json:"merchantCategory"`
+	ImageURL         *string                      `json:"imageUrl"`
+	Location         *MerchantLocationRequestBody `json:"location"`
+}
 
-	return merchant, nil
+func (body MerchantPatchRequestBody) IsEmpty() bool {
+	return body.Name == nil &&
+		body.MerchantCategory == nil &&
+		body.ImageURL == nil &&
+		body.Location == nil
+}
+
+// Apply merges the patch onto an existing merchant and validates the result
+// with the same rules as MerchantRequestBody.IsValid.
+func (body MerchantPatchRequestBody) Apply(
+	merchant Merchant,
+) (Merchant, error) {
+	full := MerchantRequestBody{
+		Name:             merchant.Name,
+		MerchantCategory: merchant.MerchantCategory,
+		ImageURL:         merchant.ImageURL,
+		Location: MerchantLocationRequestBody{
+			Lat:  merchant.Latitude,
+			Long: merchant.Longitude,
+		},
+	}
+	if body.Name != nil {
+		full.Name = *body.Name
+	}
+	if body.MerchantCategory != nil {
+		full.MerchantCategory = *body.MerchantCategory
+	}
+	if body.ImageURL != nil {
+		full.ImageURL = *body.ImageURL
+	}
+	if body.Location != nil {
+		full.Location = *body.Location
+	}
+
+	updated, err := full.IsValid()
+	if err != nil {
+		return merchant, err
+	}
+	updated.ID = merchant.ID
+	updated.UserID = merchant.UserID
+	updated.CreatedAt = merchant.CreatedAt
+
+	return updated, nil
 }
 
 type MerchantQueries struct {
 	MerchantID       string           `query:"merchantId"`
 	Name             string           `query:"name"`
 	MerchantCategory MerchantCategory `query:"merchantCategory"`
+	Sort             string           `query:"sort"`
+	UserID           uuid.UUID
 	Limit            int
 	Offset           int
 	CreatedAt        string
+	Sorts            []sqlbuilder.Sort
+	CursorQueries
 }
 
-func (q *MerchantQueries) BuildWhereClauses() ([]string, []interface{}) {
-	clauses := make([]string, 0, 4)
-	params := make([]interface{}, 0, 4)
+var merchantSortFields = sqlbuilder.SortFields{
+	"name":      "name",
+	"createdAt": "created_at",
+}
 
-	merchantId, err := uuid.Parse(
-		q.MerchantID,
+// ParseSort reads the sort parameter, such as "name,-createdAt". Without
+// it, merchants are sorted by the createdAt parameter, newest first unless
+// it is "asc". Ties are broken by id so every merchant has one position.
+func (q *MerchantQueries) ParseSort() error {
+	sorts, err := sqlbuilder.ParseSort(
+		"sort",
+		q.Sort,
+		merchantSortFields,
 	)
-	if err == nil {
-		clauses = append(
-			clauses,
-			"id = $%d",
-		)
-		params = append(
-			params,
-			merchantId,
-		)
+	if err != nil {
+		return err
 	}
 
-	if q.Name != "" {
-		clauses = append(
-			clauses,
-			"name ilike '%%' || $%d || '%%'",
-		)
-		params = append(params, q.Name)
-	}
-
-	switch q.MerchantCategory {
-	case SmallRestaurant,
-		MediumRestaurant,
-		LargeRestaurant,
-		MerchandiseRestaurant,
-		BoothKiosk,
-		ConvenienceStore:
-		clauses = append(
-			clauses,
-			"merchant_category = $%d",
-		)
-		params = append(
-			params,
-			q.MerchantCategory,
+	if len(sorts) == 0 {
+		direction := sqlbuilder.Desc
+		if OrderBy(q.CreatedAt) == Asc {
+			direction = sqlbuilder.Asc
+		}
+		sorts = append(
+			sorts,
+			sqlbuilder.SortBy("created_at", direction),
 		)
 	}
+	q.Sorts = append(
+		sorts,
+		sqlbuilder.SortBy("id", sqlbuilder.Desc),
+	)
+
+	return nil
+}
+
+var merchantColumnTypes = sqlbuilder.ColumnTypes{
+	"name":       sqlbuilder.TextColumn,
+	"created_at": sqlbuilder.TimestampColumn,
+	"id":         sqlbuilder.UUIDColumn,
+}
+
+func (q *MerchantQueries) IsValid() error {
+	v := validation.New()
+	validatePagination(v, q.Limit, q.Offset)
+
+	return v.Err()
+}
 
-	return clauses, params
+// ParseCursor reads the pagination parameters once the sort is parsed.
+func (q *MerchantQueries) ParseCursor() error {
+	return q.CursorQueries.ParseCursor(
+		q.Sorts,
+		merchantColumnTypes,
+	)
 }
 
-func (q *MerchantQueries) BuildPagination() (string, []interface{}) {
-	var params []interface{}
+func (q *MerchantQueries) Filter(query *sqlbuilder.SelectBuilder) {
+	if q.UserID != uuid.Nil {
+		query.Where("user_id = ?", q.UserID)
+	}
+
+	if merchantID, err := uuid.Parse(q.MerchantID); err == nil {
+		query.Where("id = ?", merchantID)
+	}
+
+	if q.Name != "" {
+		query.Where("name ilike '%' || ? || '%'", q.Name)
+	}
+
+	if slices.Contains(merchantCategories, q.MerchantCategory) {
+		query.Where("merchant_category = ?", q.MerchantCategory)
+	}
+}
 
+func (q *MerchantQueries) Pagination() (int, int) {
 	limit := 5
 	offset := 0
 	if q.Limit > 0 {
@@ -160,37 +269,8 @@ func (q *MerchantQueries) BuildPagination() (string, []interface{}) {
 	if q.Offset > 0 {
 		offset = q.Offset
 	}
-	params = append(
-		params,
-		limit,
-		offset,
-	)
 
-	return "limit $%d offset $%d", params
-}
-
-func (q *MerchantQueries) BuildOrderByClause() []string {
-	var sqlClause []string
-
-	if q.CreatedAt != "" ||
-		OrderBy(
-			q.CreatedAt,
-		).IsValid() {
-		sqlClause = append(
-			sqlClause,
-			fmt.Sprintf(
-				"created_at %s",
-				q.CreatedAt,
-			),
-		)
-	} else {
-		sqlClause = append(
-			sqlClause,
-			"created_at desc",
-		)
-	}
-
-	return sqlClause
+	return limit, offset
 }
 
 type MerchantResponaeBody struct {
@@ -200,6 +280,7 @@ type MerchantResponaeBody struct {
 	ImageURL         string               `json:"imageUrl"`
 	Location         LocationResponseBody `json:"location"`
 	CreatedAt        string               `json:"createdat"`
+	DeletedAt        string               `json:"deletedAt,omitempty"`
 }
 
 type LocationResponseBody struct {
@@ -208,6 +289,13 @@ type LocationResponseBody struct {
 }
 
 func (m *Merchant) ToResponseBody() MerchantResponaeBody {
+	var deletedAt string
+	if m.DeletedAt != nil {
+		deletedAt = util.ToISO8601(
+			*m.DeletedAt,
+		)
+	}
+
 	return MerchantResponaeBody{
 		MerchantID: m.ID.String(),
 		Name:       m.Name,
@@ -222,5 +310,79 @@ func (m *Merchant) ToResponseBody() MerchantResponaeBody {
 		CreatedAt: util.ToISO8601(
 			m.CreatedAt,
 		),
+		DeletedAt: deletedAt,
 	}
 }
+
+type MerchantNearbyQueries struct {
+	MerchantQueries
+	Lat  float64
+	Long float64
+}
+
+func (q *MerchantNearbyQueries) ParseCoordinates(
+	coordinates string,
+) error {
+	latString, longString, found := strings.Cut(
+		coordinates,
+		",",
+	)
+	if !found {
+		return validation.New().Add(
+			"coordinates",
+			"format",
+			"must be formatted as lat,long",
+		).Err()
+	}
+
+	v := validation.New()
+	lat, err := strconv.ParseFloat(
+		strings.TrimSpace(latString),
+		64,
+	)
+	if err != nil {
+		v.Add("coordinates.lat", "number", "must be a number")
+	} else {
+		v.Field("coordinates.lat", validation.Latitude(lat))
+	}
+
+	long, err := strconv.ParseFloat(
+		strings.TrimSpace(longString),
+		64,
+	)
+	if err != nil {
+		v.Add("coordinates.long", "number", "must be a number")
+	} else {
+		v.Field("coordinates.long", validation.Longitude(long))
+	}
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	q.Lat = lat
+	q.Long = long
+	return nil
+}
+
+// OrderByDistance orders merchants by their great-circle (haversine)
+// distance in kilometers from the requested coordinates.
+func (q *MerchantNearbyQueries) OrderByDistance(query *sqlbuilder.SelectBuilder) {
+	query.OrderByExpr(
+		`6371 * 2 * asin(sqrt(
+        power(sin(radians(latitude - ?) / 2), 2) +
+        cos(radians(?)) * cos(radians(latitude)) *
+        power(sin(radians(longitude - ?) / 2), 2)
+      )) asc`,
+		q.Lat,
+		q.Lat,
+		q.Long,
+	).OrderBy(
+		sqlbuilder.SortBy("created_at", sqlbuilder.Desc),
+		sqlbuilder.SortBy("id", sqlbuilder.Desc),
+	)
+}
+
+type MerchantNearbyResponseBody struct {
+	Merchant MerchantResponaeBody `json:"merchant"`
+	Items    []ProductData        `json:"items"`
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/validation"
+)
+
+// MFA is the totp enrolment of a user. Secret is encrypted at rest and
+// ConfirmedAt stays nil until the user has proven their authenticator
+// works. LastUsedStep keeps a code from being accepted twice.
+type MFA struct {
+	UserID       uuid.UUID
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+type RecoveryCode struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// NormalizeRecoveryCode drops the separators and the case of a recovery
+// code so it can be typed back however it was written down.
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToLower(
+		strings.NewReplacer("-", "", " ", "").Replace(code),
+	)
+}
+
+// MFAEnrolment holds what an authenticator app needs, either typed in as
+// the secret or scanned as a QR code of the provisioning URI.
+type MFAEnrolment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+type MFAEnrolmentResponseBody struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+func (e MFAEnrolment) ToResponseBody() MFAEnrolmentResponseBody {
+	return MFAEnrolmentResponseBody{
+		Secret:          e.Secret,
+		ProvisioningURI: e.ProvisioningURI,
+	}
+}
+
+type MFASettings struct {
+	RequiredForAdmins bool
+}
+
+type MFASettingsResponseBody struct {
+	RequiredForAdmins bool `json:"requiredForAdmins"`
+}
+
+func (s MFASettings) ToResponseBody() MFASettingsResponseBody {
+	return MFASettingsResponseBody{
+		RequiredForAdmins: s.RequiredForAdmins,
+	}
+}
+
+type MFASettingsRequestBody struct {
+	RequiredForAdmins *bool `json:"requiredForAdmins"`
+}
+
+func (body MFASettingsRequestBody) IsValid() (MFASettings, error) {
+	err := validation.New().Field(
+		"requiredForAdmins",
+		validation.NotNil(body.RequiredForAdmins),
+	).Err()
+	if err != nil {
+		return MFASettings{}, err
+	}
+
+	return MFASettings{
+		RequiredForAdmins: *body.RequiredForAdmins,
+	}, nil
+}
+
+type MFACodeRequestBody struct {
+	Code string `json:"code"`
+}
+
+func (body MFACodeRequestBody) IsValid() (string, error) {
+	err := validation.New().Field(
+		"code",
+		validation.Required(body.Code),
+	).Err()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(body.Code), nil
+}
+
+type MFATokenRequestBody struct {
+	MFAToken string `json:"mfaToken"`
+}
+
+func (body MFATokenRequestBody) IsValid() (string, error) {
+	err := validation.New().Field(
+		"mfaToken",
+		validation.Required(body.MFAToken),
+	).Err()
+	if err != nil {
+		return "", err
+	}
+
+	return body.MFAToken, nil
+}
+
+// MFAVerifyRequestBody completes a login with either a totp code or one
+// of the recovery codes.
+type MFAVerifyRequestBody struct {
+	MFAToken string `json:"mfaToken"`
+	Code     string `json:"code"`
+}
+
+func (body MFAVerifyRequestBody) IsValid() (string, string, error) {
+	v := validation.New()
+	v.Field(
+		"mfaToken",
+		validation.Required(body.MFAToken),
+	)
+	v.Field(
+		"code",
+		validation.Required(body.Code),
+	)
+	if err := v.Err(); err != nil {
+		return "", "", err
+	}
+
+	return body.MFAToken, strings.TrimSpace(body.Code), nil
+}
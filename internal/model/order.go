@@ -0,0 +1,116 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/sqlbuilder"
+	"github.com/nozzlium/belimang/internal/validation"
+)
+
+type Order struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	EstimateID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type OrderItem struct {
+	OrderID  uuid.UUID
+	Merchant Merchant
+	Product  Product
+	Quantity int
+}
+
+type OrderRequestBody struct {
+	CalculatedEstimateID string `json:"calculatedEstimateId"`
+}
+
+func (body OrderRequestBody) IsValid() (Order, error) {
+	err := validation.New().Field(
+		"calculatedEstimateId",
+		validation.UUID(body.CalculatedEstimateID),
+	).Err()
+	if err != nil {
+		return Order{}, err
+	}
+
+	return Order{
+		EstimateID: uuid.MustParse(body.CalculatedEstimateID),
+	}, nil
+}
+
+type OrderQueries struct {
+	MerchantID       string           `query:"merchantId"`
+	Name             string           `query:"name"`
+	MerchantCategory MerchantCategory `query:"merchantCategory"`
+	UserID           uuid.UUID
+	Limit            int
+	Offset           int
+}
+
+func (q *OrderQueries) IsValid() error {
+	v := validation.New()
+	validatePagination(v, q.Limit, q.Offset)
+
+	return v.Err()
+}
+
+// Filter narrows a query over orders o, joined with their estimate items
+// ei and the merchants m and products p of those items, down to the
+// caller's orders and the items matching the requested filters.
+func (q *OrderQueries) Filter(query *sqlbuilder.SelectBuilder) {
+	query.Where("o.user_id = ?", q.UserID)
+
+	if merchantID, err := uuid.Parse(q.MerchantID); err == nil {
+		query.Where("m.id = ?", merchantID)
+	}
+
+	if q.Name != "" {
+		query.Where(
+			"(m.name ilike '%' || ? || '%' or p.name ilike '%' || ? || '%')",
+			q.Name,
+			q.Name,
+		)
+	}
+
+	if slices.Contains(merchantCategories, q.MerchantCategory) {
+		query.Where("m.merchant_category = ?", q.MerchantCategory)
+	}
+}
+
+func (q *OrderQueries) Pagination() (int, int) {
+	limit := 5
+	offset := 0
+	if q.Limit > 0 {
+		limit = q.Limit
+	}
+	if q.Offset > 0 {
+		offset = q.Offset
+	}
+
+	return limit, offset
+}
+
+type OrderResponseBody struct {
+	OrderID string                      `json:"orderId"`
+	Orders  []OrderMerchantResponseBody `json:"orders"`
+}
+
+type OrderMerchantResponseBody struct {
+	Merchant MerchantResponaeBody    `json:"merchant"`
+	Items    []OrderItemResponseBody `json:"items"`
+}
+
+type OrderItemResponseBody struct {
+	ProductData
+	Quantity int `json:"quantity"`
+}
+
+func (item *OrderItem) ToItemResponseBody() OrderItemResponseBody {
+	return OrderItemResponseBody{
+		ProductData: item.Product.ToProductData(),
+		Quantity:    item.Quantity,
+	}
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"github.com/nozzlium/belimang/internal/sqlbuilder"
+	"github.com/nozzlium/belimang/internal/validation"
+)
+
+// MaxLimit is the most rows a listing returns at once.
+const MaxLimit = 100
+
+// validatePagination rejects limits outside 1 to MaxLimit and negative
+// offsets, so a request cannot make a listing read or hold unbounded rows.
+func validatePagination(
+	v *validation.Validator,
+	limit int,
+	offset int,
+) {
+	v.Field(
+		"limit",
+		validation.Range(limit, 1, MaxLimit),
+	)
+	v.Field(
+		"offset",
+		validation.Min(offset, 0),
+	)
+}
+
+type PaginationMode string
+
+const (
+	OffsetPagination PaginationMode = "offset"
+	CursorPagination PaginationMode = "cursor"
+)
+
+// TotalMode tells whether a cursor paginated listing counts its rows.
+// Exact counts them all, Estimate takes the planner's row estimate, which
+// is cheap but can be off on large or recently changed tables.
+type TotalMode string
+
+const (
+	TotalNone     TotalMode = "none"
+	TotalExact    TotalMode = "exact"
+	TotalEstimate TotalMode = "estimate"
+)
+
+// CursorQueries switch a listing from limit and offset to keyset
+// pagination, which stays fast however deep clients page and does not
+// skip or repeat rows when others are inserted in between.
+type CursorQueries struct {
+	Mode     PaginationMode
+	Cursor   string
+	Total    TotalMode
+	position *sqlbuilder.Cursor
+}
+
+func (q CursorQueries) IsCursor() bool {
+	return q.Mode == CursorPagination || q.Cursor != ""
+}
+
+// ParseCursor checks the pagination parameters and reads the cursor, which
+// has to have been made for sorts over columns of types.
+func (q *CursorQueries) ParseCursor(
+	sorts []sqlbuilder.Sort,
+	types sqlbuilder.ColumnTypes,
+) error {
+	v := validation.New()
+	v.Field(
+		"pagination",
+		validation.OneOf(q.Mode, OffsetPagination, CursorPagination),
+	)
+	v.Field(
+		"total",
+		validation.OneOf(q.Total, TotalNone, TotalExact, TotalEstimate),
+	)
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	if q.Cursor == "" {
+		return nil
+	}
+	position, err := sqlbuilder.DecodeCursor(
+		"cursor",
+		q.Cursor,
+		sorts,
+		types,
+	)
+	if err != nil {
+		return err
+	}
+	q.position = &position
+
+	return nil
+}
+
+// Position is the row to page on from, or nil for the first page.
+func (q CursorQueries) Position() *sqlbuilder.Cursor {
+	return q.position
+}
+
+// Page is one page of a cursor paginated listing. Next and Prev are empty
+// when there is no such page, Total is nil unless it was asked for.
+type Page[T any] struct {
+	Items []T
+	Next  string
+	Prev  string
+	Total *int
+}
+
+type CursorMeta struct {
+	Limit          int     `json:"limit"`
+	Next           *string `json:"next"`
+	Prev           *string `json:"prev"`
+	Total          *int    `json:"total,omitempty"`
+	TotalEstimated bool    `json:"totalEstimated,omitempty"`
+}
+
+func NewCursorMeta[T any](
+	page Page[T],
+	limit int,
+	total TotalMode,
+) CursorMeta {
+	meta := CursorMeta{
+		Limit:          limit,
+		Total:          page.Total,
+		TotalEstimated: total == TotalEstimate,
+	}
+	if page.Next != "" {
+		meta.Next = &page.Next
+	}
+	if page.Prev != "" {
+		meta.Prev = &page.Prev
+	}
+
+	return meta
+}
@@ -1,13 +1,14 @@
 package model
 
 import (
-	"fmt"
-	"log"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/sqlbuilder"
 	"github.com/nozzlium/belimang/internal/util"
+	"github.com/nozzlium/belimang/internal/validation"
 )
 
 type (
@@ -30,7 +31,23 @@ type Product struct {
 	Price           float64
 	ProductCategory ProductCategory
 	ImageURL        string
+	IsAvailable     bool
 	CreatedAt       time.Time
+	DeletedAt       *time.Time
+}
+
+// CursorValue returns the value of a sorted column as text for a cursor.
+func (p Product) CursorValue(column string) string {
+	switch column {
+	case "name":
+		return p.Name
+	case "price":
+		return strconv.FormatFloat(p.Price, 'f', -1, 64)
+	case "created_at":
+		return p.CreatedAt.Format(sqlbuilder.TimestampLayout)
+	default:
+		return p.ID.String()
+	}
 }
 
 type ProductRequestBody struct {
@@ -38,114 +55,203 @@ type ProductRequestBody struct {
 	ProductCategory ProductCategory `json:"productCategory"`
 	Price           float64         `json:"price"`
 	ImageUrl        string          `json:"imageUrl"`
+	IsAvailable     *bool           `json:"isAvailable"`
+}
+
+var productCategories = []ProductCategory{
+	Beverage,
+	Food,
+	Snack,
+	Condiments,
+	Additions,
 }
 
 func (body ProductRequestBody) IsValid() (Product, error) {
-	var product Product
-	if nameLen := len(body.Name); nameLen < 2 ||
-		nameLen > 30 {
-		log.Println("di nama")
-		return product, constant.ErrBadInput
-	}
-	product.Name = body.Name
-
-	switch body.ProductCategory {
-	case Beverage,
-		Food,
-		Snack,
-		Condiments,
-		Additions:
-		product.ProductCategory = body.ProductCategory
-	default:
-		log.Println("di kategori")
-		return product, constant.ErrBadInput
+	v := validation.New()
+	v.Field(
+		"name",
+		validation.Length(body.Name, 2, 30),
+	)
+	v.Field(
+		"productCategory",
+		validation.OneOf(body.ProductCategory, productCategories...),
+	)
+	v.Field(
+		"price",
+		validation.Min(body.Price, 1),
+	)
+	v.Field(
+		"imageUrl",
+		validation.URL(body.ImageUrl),
+	)
+	if err := v.Err(); err != nil {
+		return Product{}, err
 	}
 
-	if body.Price < 1 {
-		log.Println("di harga")
-		return product, constant.ErrBadInput
+	product := Product{
+		Name:            body.Name,
+		ProductCategory: body.ProductCategory,
+		Price:           body.Price,
+		ImageURL:        body.ImageUrl,
+		IsAvailable:     true,
+	}
+	if body.IsAvailable != nil {
+		product.IsAvailable = *body.IsAvailable
 	}
-	product.Price = body.Price
 
-	err := util.ValidateURL(
-		body.ImageUrl,
-	)
+	return product, nil
+}
+
+type ProductPatchRequestBody struct {
+	Name            *string          `json:"name"`
+	ProductCategory *ProductCategory `json:"productCategory"`
+	Price           *float64         `json:"price"`
+	ImageUrl        *string          `json:"imageUrl"`
+	IsAvailable     *bool            `json:"isAvailable"`
+}
+
+func (body ProductPatchRequestBody) IsEmpty() bool {
+	return body.Name == nil &&
+		body.ProductCategory == nil &&
+		body.Price == nil &&
+		body.ImageUrl == nil &&
+		body.IsAvailable == nil
+}
+
+// Apply merges the patch onto an existing product and validates the result
+// with the same rules as ProductRequestBody.IsValid.
+func (body ProductPatchRequestBody) Apply(
+	product Product,
+) (Product, error) {
+	full := ProductRequestBody{
+		Name:            product.Name,
+		ProductCategory: product.ProductCategory,
+		Price:           product.Price,
+		ImageUrl:        product.ImageURL,
+		IsAvailable:     &product.IsAvailable,
+	}
+	if body.Name != nil {
+		full.Name = *body.Name
+	}
+	if body.ProductCategory != nil {
+		full.ProductCategory = *body.ProductCategory
+	}
+	if body.Price != nil {
+		full.Price = *body.Price
+	}
+	if body.ImageUrl != nil {
+		full.ImageUrl = *body.ImageUrl
+	}
+	if body.IsAvailable != nil {
+		full.IsAvailable = body.IsAvailable
+	}
+
+	updated, err := full.IsValid()
 	if err != nil {
-		log.Println("di url")
-		return product, constant.ErrBadInput
+		return product, err
 	}
-	product.ImageURL = body.ImageUrl
+	updated.ID = product.ID
+	updated.UserID = product.UserID
+	updated.MerchantID = product.MerchantID
+	updated.CreatedAt = product.CreatedAt
 
-	return product, nil
+	return updated, nil
 }
 
 type ProductQueries struct {
 	ItemID          string          `query:"itemId"`
 	Name            string          `query:"name"`
 	ProductCategory ProductCategory `query:"productCategory"`
+	Sort            string          `query:"sort"`
 	MerchantId      uuid.UUID
+	IsAvailable     *bool
 	Limit           int
 	Offset          int
 	CreatedAt       string
+	Sorts           []sqlbuilder.Sort
+	CursorQueries
 }
 
-func (q *ProductQueries) BuildWhereClauses() ([]string, []interface{}) {
-	clauses := make([]string, 0, 4)
-	params := make([]interface{}, 0, 4)
+var productSortFields = sqlbuilder.SortFields{
+	"name":      "name",
+	"price":     "price",
+	"createdAt": "created_at",
+}
 
-	clauses = append(
-		clauses,
-		"merchant_id = $%d",
+// ParseSort reads the sort parameter, such as "-price,name". Without it,
+// items are sorted by the createdAt parameter, newest first unless it is
+// "asc". Ties are broken by id so every item has one position.
+func (q *ProductQueries) ParseSort() error {
+	sorts, err := sqlbuilder.ParseSort(
+		"sort",
+		q.Sort,
+		productSortFields,
 	)
-	params = append(
-		params,
-		q.MerchantId,
+	if err != nil {
+		return err
+	}
+
+	if len(sorts) == 0 {
+		direction := sqlbuilder.Desc
+		if OrderBy(q.CreatedAt) == Asc {
+			direction = sqlbuilder.Asc
+		}
+		sorts = append(
+			sorts,
+			sqlbuilder.SortBy("created_at", direction),
+		)
+	}
+	q.Sorts = append(
+		sorts,
+		sqlbuilder.SortBy("id", sqlbuilder.Desc),
 	)
 
-	itemId, err := uuid.Parse(
-		q.ItemID,
+	return nil
+}
+
+var productColumnTypes = sqlbuilder.ColumnTypes{
+	"name":       sqlbuilder.TextColumn,
+	"price":      sqlbuilder.NumericColumn,
+	"created_at": sqlbuilder.TimestampColumn,
+	"id":         sqlbuilder.UUIDColumn,
+}
+
+func (q *ProductQueries) IsValid() error {
+	v := validation.New()
+	validatePagination(v, q.Limit, q.Offset)
+
+	return v.Err()
+}
+
+// ParseCursor reads the pagination parameters once the sort is parsed.
+func (q *ProductQueries) ParseCursor() error {
+	return q.CursorQueries.ParseCursor(
+		q.Sorts,
+		productColumnTypes,
 	)
-	if err == nil {
-		clauses = append(
-			clauses,
-			"id = $%d",
-		)
-		params = append(
-			params,
-			itemId,
-		)
+}
+
+func (q *ProductQueries) Filter(query *sqlbuilder.SelectBuilder) {
+	query.Where("merchant_id = ?", q.MerchantId)
+
+	if itemID, err := uuid.Parse(q.ItemID); err == nil {
+		query.Where("id = ?", itemID)
 	}
 
 	if q.Name != "" {
-		clauses = append(
-			clauses,
-			"name ilike '%%' || $%d || '%%'",
-		)
-		params = append(params, q.Name)
-	}
-
-	switch q.ProductCategory {
-	case Beverage,
-		Food,
-		Snack,
-		Condiments,
-		Additions:
-		clauses = append(
-			clauses,
-			"product_category = $%d",
-		)
-		params = append(
-			params,
-			q.ProductCategory,
-		)
+		query.Where("name ilike '%' || ? || '%'", q.Name)
 	}
 
-	return clauses, params
-}
+	if slices.Contains(productCategories, q.ProductCategory) {
+		query.Where("product_category = ?", q.ProductCategory)
+	}
 
-func (q *ProductQueries) BuildPagination() (string, []interface{}) {
-	var params []interface{}
+	if q.IsAvailable != nil {
+		query.Where("is_available = ?", *q.IsAvailable)
+	}
+}
 
+func (q *ProductQueries) Pagination() (int, int) {
 	limit := 5
 	offset := 0
 	if q.Limit > 0 {
@@ -154,37 +260,8 @@ func (q *ProductQueries) BuildPagination() (string, []interface{}) {
 	if q.Offset > 0 {
 		offset = q.Offset
 	}
-	params = append(
-		params,
-		limit,
-		offset,
-	)
-
-	return "limit $%d offset $%d", params
-}
-
-func (q *ProductQueries) BuildOrderByClause() []string {
-	var sqlClause []string
-
-	if q.CreatedAt != "" ||
-		OrderBy(
-			q.CreatedAt,
-		).IsValid() {
-		sqlClause = append(
-			sqlClause,
-			fmt.Sprintf(
-				"created_at %s",
-				q.CreatedAt,
-			),
-		)
-	} else {
-		sqlClause = append(
-			sqlClause,
-			"created_at desc",
-		)
-	}
 
-	return sqlClause
+	return limit, offset
 }
 
 type ProductItemsResponseBody struct {
@@ -198,6 +275,7 @@ type ProductData struct {
 	ProductCategory string  `json:"productCategory"`
 	Price           float64 `json:"price"`
 	ImageURl        string  `json:"imageUrl"`
+	IsAvailable     bool    `json:"isAvailable"`
 	CreatedAt       string  `json:"createdAt"`
 }
 
@@ -208,14 +286,20 @@ func (product *Product) ToProductData() ProductData {
 		ProductCategory: string(
 			product.ProductCategory,
 		),
-		Price:    product.Price,
-		ImageURl: product.ImageURL,
+		Price:       product.Price,
+		ImageURl:    product.ImageURL,
+		IsAvailable: product.IsAvailable,
 		CreatedAt: util.ToISO8601(
 			product.CreatedAt,
 		),
 	}
 }
 
+type ProductPageResponseBody struct {
+	Data []ProductData `json:"data"`
+	Meta CursorMeta    `json:"meta"`
+}
+
 type ProductMeta struct {
 	Limit  int `json:"limit"`
 	Offset int `json:"offset"`
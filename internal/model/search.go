@@ -0,0 +1,163 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/nozzlium/belimang/internal/sqlbuilder"
+	"github.com/nozzlium/belimang/internal/util"
+	"github.com/nozzlium/belimang/internal/validation"
+)
+
+type SearchQueries struct {
+	Query            string           `query:"q"`
+	MerchantCategory MerchantCategory `query:"merchantCategory"`
+	ProductCategory  ProductCategory  `query:"productCategory"`
+	Limit            int
+	Offset           int
+}
+
+func (q *SearchQueries) IsValid() error {
+	q.Query = strings.TrimSpace(q.Query)
+
+	v := validation.New()
+	v.Field(
+		"q",
+		validation.Required(q.Query),
+		validation.Length(q.Query, 1, 100),
+	)
+	if q.MerchantCategory != "" {
+		v.Field(
+			"merchantCategory",
+			validation.OneOf(q.MerchantCategory, merchantCategories...),
+		)
+	}
+	if q.ProductCategory != "" {
+		v.Field(
+			"productCategory",
+			validation.OneOf(q.ProductCategory, productCategories...),
+		)
+	}
+	validatePagination(v, q.Limit, q.Offset)
+
+	return v.Err()
+}
+
+// FilterMerchants narrows merchant hits, selected from merchants m, down to
+// the requested categories. With a product category, only merchants
+// selling items of it are found.
+func (q *SearchQueries) FilterMerchants(query *sqlbuilder.SelectBuilder) {
+	if q.MerchantCategory != "" {
+		query.Where("m.merchant_category = ?", q.MerchantCategory)
+	}
+
+	if q.ProductCategory != "" {
+		query.Where(
+			`exists (
+        select 1 from products p
+        where p.merchant_id = m.id
+          and p.deleted_at is null
+          and p.product_category = ?
+      )`,
+			q.ProductCategory,
+		)
+	}
+}
+
+// FilterItems narrows item hits, selected from products p joined with
+// merchants m, down to the requested categories.
+func (q *SearchQueries) FilterItems(query *sqlbuilder.SelectBuilder) {
+	if q.MerchantCategory != "" {
+		query.Where("m.merchant_category = ?", q.MerchantCategory)
+	}
+
+	if q.ProductCategory != "" {
+		query.Where("p.product_category = ?", q.ProductCategory)
+	}
+}
+
+func (q *SearchQueries) Pagination() (int, int) {
+	limit := 5
+	offset := 0
+	if q.Limit > 0 {
+		limit = q.Limit
+	}
+	if q.Offset > 0 {
+		offset = q.Offset
+	}
+
+	return limit, offset
+}
+
+type SearchHitType string
+
+const (
+	MerchantHit SearchHitType = "merchant"
+	ItemHit     SearchHitType = "item"
+)
+
+// SearchHit is a merchant whose name matched a search, or an item whose
+// name did together with the merchant selling it. Score is the word
+// similarity between the search and the matched name, from 0 to 1.
+type SearchHit struct {
+	Type     SearchHitType
+	Merchant Merchant
+	Item     *Product
+	Score    float64
+}
+
+// HighlightResponseBody marks a matched word of a name by its character
+// offsets, start inclusive and end exclusive.
+type HighlightResponseBody struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type SearchHitResponseBody struct {
+	Type       SearchHitType           `json:"type"`
+	Score      float64                 `json:"score"`
+	Merchant   MerchantResponaeBody    `json:"merchant"`
+	Item       *ProductData            `json:"item,omitempty"`
+	Highlights []HighlightResponseBody `json:"highlights"`
+}
+
+// ToResponseBody highlights the words of the matched name, the item name
+// for item hits, that resemble a word of query by at least threshold.
+func (h SearchHit) ToResponseBody(
+	query string,
+	threshold float64,
+) SearchHitResponseBody {
+	body := SearchHitResponseBody{
+		Type:     h.Type,
+		Score:    h.Score,
+		Merchant: h.Merchant.ToResponseBody(),
+	}
+
+	name := h.Merchant.Name
+	if h.Item != nil {
+		item := h.Item.ToProductData()
+		body.Item = &item
+		name = h.Item.Name
+	}
+
+	matches := util.SimilarWords(
+		name,
+		query,
+		threshold,
+	)
+	body.Highlights = make(
+		[]HighlightResponseBody,
+		0,
+		len(matches),
+	)
+	for _, match := range matches {
+		body.Highlights = append(
+			body.Highlights,
+			HighlightResponseBody{
+				Start: match[0],
+				End:   match[1],
+			},
+		)
+	}
+
+	return body
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/validation"
+)
+
+type RefreshToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	FamilyID  uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+type TokenPurpose string
+
+const (
+	TokenPurposeVerifyEmail   TokenPurpose = "verify_email"
+	TokenPurposeResetPassword TokenPurpose = "reset_password"
+	TokenPurposeMFALogin      TokenPurpose = "mfa_login"
+)
+
+// ActionToken is a single use token handed to the user to confirm an
+// action, either by mail or, for a login waiting on its second factor, in
+// the login response. Like refresh tokens, only a hash of it is stored.
+type ActionToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Purpose   TokenPurpose
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// AuthTokens is the outcome of a login. When the account needs a second
+// factor it only holds MFAToken, which POST /auth/mfa/verify exchanges for
+// the access and refresh tokens. RecoveryCodes is only set right after
+// two-factor enrolment completed during that exchange.
+type AuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+
+	MFAToken             string
+	MFAEnrolmentRequired bool
+	RecoveryCodes        []string
+}
+
+type RefreshRequestBody struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (body RefreshRequestBody) IsValid() (string, error) {
+	err := validation.New().Field(
+		"refreshToken",
+		validation.Required(body.RefreshToken),
+	).Err()
+	if err != nil {
+		return "", err
+	}
+
+	return body.RefreshToken, nil
+}
+
+type AuthTokensResponseBody struct {
+	Token                string   `json:"token,omitempty"`
+	RefreshToken         string   `json:"refreshToken,omitempty"`
+	MFARequired          bool     `json:"mfaRequired,omitempty"`
+	MFAToken             string   `json:"mfaToken,omitempty"`
+	MFAEnrolmentRequired bool     `json:"mfaEnrolmentRequired,omitempty"`
+	RecoveryCodes        []string `json:"recoveryCodes,omitempty"`
+}
+
+func (t AuthTokens) ToResponseBody() AuthTokensResponseBody {
+	return AuthTokensResponseBody{
+		Token:                t.AccessToken,
+		RefreshToken:         t.RefreshToken,
+		MFARequired:          t.MFAToken != "",
+		MFAToken:             t.MFAToken,
+		MFAEnrolmentRequired: t.MFAEnrolmentRequired,
+		RecoveryCodes:        t.RecoveryCodes,
+	}
+}
+
+type VerifyEmailRequestBody struct {
+	Token string `json:"token"`
+}
+
+func (body VerifyEmailRequestBody) IsValid() (string, error) {
+	err := validation.New().Field(
+		"token",
+		validation.Required(body.Token),
+	).Err()
+	if err != nil {
+		return "", err
+	}
+
+	return body.Token, nil
+}
+
+type ForgotPasswordRequestBody struct {
+	Email string `json:"email"`
+}
+
+func (body ForgotPasswordRequestBody) IsValid() (string, error) {
+	err := validation.New().Field(
+		"email",
+		validation.Email(body.Email),
+	).Err()
+	if err != nil {
+		return "", err
+	}
+
+	return body.Email, nil
+}
+
+type ResetPasswordRequestBody struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (body ResetPasswordRequestBody) IsValid() (string, string, error) {
+	v := validation.New()
+	v.Field(
+		"token",
+		validation.Required(body.Token),
+	)
+	v.Field(
+		"password",
+		validation.Length(body.Password, 5, 30),
+		validation.MaxBytes(body.Password, PasswordMaxBytes),
+	)
+	if err := v.Err(); err != nil {
+		return "", "", err
+	}
+
+	return body.Token, body.Password, nil
+}
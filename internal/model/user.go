@@ -1,16 +1,58 @@
 package model
 
 import (
+	"slices"
+	"time"
+
 	"github.com/google/uuid"
-	"github.com/nozzlium/belimang/internal/constant"
-	"github.com/nozzlium/belimang/internal/util"
+	"github.com/nozzlium/belimang/internal/validation"
 )
 
+type Role string
+
+const (
+	RoleAdmin Role = "admin"
+	RoleUser  Role = "user"
+	// RoleOperator runs the deployment, next to being an admin. It is only
+	// granted with the operator subcommand, never through the api.
+	RoleOperator Role = "operator"
+)
+
+// PasswordMaxBytes is as much of a password as bcrypt reads, so longer
+// passwords, which fit the character limit when they are not ascii, are
+// rejected instead of cut short silently.
+const PasswordMaxBytes = 72
+
 type User struct {
 	ID       uuid.UUID
 	Username string
 	Email    string
 	Password string
+	Roles    []Role
+
+	EmailVerifiedAt *time.Time
+}
+
+func (u User) HasRole(role Role) bool {
+	return slices.Contains(u.Roles, role)
+}
+
+type UserResponseBody struct {
+	UserID        string `json:"userId"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailVerified"`
+	Roles         []Role `json:"roles"`
+}
+
+func (u User) ToResponseBody() UserResponseBody {
+	return UserResponseBody{
+		UserID:        u.ID.String(),
+		Username:      u.Username,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerifiedAt != nil,
+		Roles:         u.Roles,
+	}
 }
 
 type UserRegisterBody struct {
@@ -20,45 +62,144 @@ type UserRegisterBody struct {
 }
 
 func (body UserRegisterBody) IsValid() (User, error) {
-	var user User
-	if unameLen := len(body.Username); unameLen < 5 ||
-		unameLen > 30 {
-		return user, constant.ErrInvalidBody
-	}
-	user.Username = body.Username
-
-	if passLen := len(body.Password); passLen < 5 ||
-		passLen > 30 {
-		return user, constant.ErrBadInput
+	v := validation.New()
+	v.Field(
+		"username",
+		validation.Length(body.Username, 5, 30),
+	)
+	v.Field(
+		"password",
+		validation.Length(body.Password, 5, 30),
+		validation.MaxBytes(body.Password, PasswordMaxBytes),
+	)
+	v.Field(
+		"email",
+		validation.Email(body.Email),
+	)
+	if err := v.Err(); err != nil {
+		return User{}, err
 	}
-	user.Password = body.Password
 
-	if err := util.ValidateEmailAddress(body.Email); err != nil {
-		return user, err
-	}
-	user.Email = body.Email
-
-	return user, nil
+	return User{
+		Username: body.Username,
+		Password: body.Password,
+		Email:    body.Email,
+	}, nil
 }
 
+// UserLoginBody identifies the account by either its username or its
+// email, both matched case-insensitively.
 type UserLoginBody struct {
 	Username string `json:"username"`
+	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
 func (body *UserLoginBody) IsValid() (User, error) {
-	var user User
-	if unameLen := len(body.Username); unameLen < 5 ||
-		unameLen > 30 {
-		return user, constant.ErrInvalidBody
+	v := validation.New()
+	switch {
+	case body.Username != "" && body.Email != "":
+		v.Add(
+			"email",
+			"exclusive",
+			"must not be given together with username",
+		)
+	case body.Email != "":
+		v.Field(
+			"email",
+			validation.Email(body.Email),
+		)
+	default:
+		v.Field(
+			"username",
+			validation.Length(body.Username, 5, 30),
+		)
 	}
-	user.Username = body.Username
+	v.Field(
+		"password",
+		validation.Length(body.Password, 5, 30),
+		validation.MaxBytes(body.Password, PasswordMaxBytes),
+	)
+	if err := v.Err(); err != nil {
+		return User{}, err
+	}
+
+	return User{
+		Username: body.Username,
+		Email:    body.Email,
+		Password: body.Password,
+	}, nil
+}
+
+// UserPatchRequestBody changes the caller's own account. Changing the
+// password requires CurrentPassword.
+type UserPatchRequestBody struct {
+	Username        *string `json:"username"`
+	Email           *string `json:"email"`
+	Password        *string `json:"password"`
+	CurrentPassword string  `json:"currentPassword"`
+}
 
-	if passLen := len(body.Password); passLen < 5 ||
-		passLen > 30 {
-		return user, constant.ErrBadInput
+func (body UserPatchRequestBody) IsEmpty() bool {
+	return body.Username == nil &&
+		body.Email == nil &&
+		body.Password == nil
+}
+
+// Apply merges the username and email of the patch onto an existing user
+// and validates them with the same rules as UserRegisterBody.IsValid. The
+// password is only validated, hashing it is up to the caller.
+func (body UserPatchRequestBody) Apply(
+	user User,
+) (User, error) {
+	v := validation.New()
+	if body.Username != nil {
+		v.Field(
+			"username",
+			validation.Length(*body.Username, 5, 30),
+		)
+		user.Username = *body.Username
+	}
+
+	if body.Password != nil {
+		v.Field(
+			"password",
+			validation.Length(*body.Password, 5, 30),
+			validation.MaxBytes(*body.Password, PasswordMaxBytes),
+		)
+		v.Field(
+			"currentPassword",
+			validation.Required(body.CurrentPassword),
+		)
+	}
+
+	if body.Email != nil {
+		v.Field(
+			"email",
+			validation.Email(*body.Email),
+		)
+		user.Email = *body.Email
+	}
+
+	if err := v.Err(); err != nil {
+		return User{}, err
 	}
-	user.Password = body.Password
 
 	return user, nil
 }
+
+type RoleRequestBody struct {
+	Role Role `json:"role"`
+}
+
+func (body RoleRequestBody) IsValid() (Role, error) {
+	err := validation.New().Field(
+		"role",
+		validation.OneOf(body.Role, RoleAdmin, RoleUser),
+	).Err()
+	if err != nil {
+		return "", err
+	}
+
+	return body.Role, nil
+}
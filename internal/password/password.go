@@ -0,0 +1,69 @@
+package password
+
+import "errors"
+
+var ErrUnknownHash = errors.New("password hash format is not recognized")
+
+// Hasher hashes passwords into a self-describing string that records the
+// algorithm and its parameters, so hashes made with older settings can
+// still be verified.
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(hash string, password string) (bool, error)
+	// Recognizes reports whether hash was produced by this algorithm.
+	Recognizes(hash string) bool
+	// NeedsRehash reports whether hash was produced with weaker
+	// parameters than the hasher is configured with.
+	NeedsRehash(hash string) bool
+}
+
+// Chain hashes new passwords with the preferred hasher and verifies
+// existing hashes with whichever hasher recognizes them.
+type Chain struct {
+	preferred Hasher
+	hashers   []Hasher
+}
+
+func NewChain(
+	preferred Hasher,
+	legacy ...Hasher,
+) *Chain {
+	return &Chain{
+		preferred: preferred,
+		hashers:   append([]Hasher{preferred}, legacy...),
+	}
+}
+
+func (c *Chain) Hash(password string) (string, error) {
+	return c.preferred.Hash(password)
+}
+
+func (c *Chain) Verify(
+	hash string,
+	password string,
+) (bool, error) {
+	for _, hasher := range c.hashers {
+		if hasher.Recognizes(hash) {
+			return hasher.Verify(hash, password)
+		}
+	}
+
+	return false, ErrUnknownHash
+}
+
+func (c *Chain) Recognizes(hash string) bool {
+	for _, hasher := range c.hashers {
+		if hasher.Recognizes(hash) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NeedsRehash is true for every hash the preferred hasher did not produce
+// with its current parameters.
+func (c *Chain) NeedsRehash(hash string) bool {
+	return !c.preferred.Recognizes(hash) ||
+		c.preferred.NeedsRehash(hash)
+}
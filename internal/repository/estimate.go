@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
+)
+
+type EstimateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewEstimateRepository(
+	db *pgxpool.Pool,
+) *EstimateRepository {
+	return &EstimateRepository{db: db}
+}
+
+func (r *EstimateRepository) Insert(
+	ctx context.Context,
+	estimate model.Estimate,
+) (model.Estimate, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return estimate, err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+
+	queryInsertEstimate := `
+    insert into
+    calculated_estimates (
+      id,
+      user_id,
+      user_latitude,
+      user_longitude,
+      total_price,
+      estimated_delivery_time_in_minutes,
+      created_at
+    ) values (
+      $1, $2, $3, $4, $5, $6, $7
+    );
+  `
+	batch.Queue(
+		queryInsertEstimate,
+		estimate.ID,
+		estimate.UserID,
+		estimate.UserLatitude,
+		estimate.UserLongitude,
+		estimate.TotalPrice,
+		estimate.EstimatedDeliveryTimeInMinutes,
+		estimate.CreatedAt,
+	)
+
+	queryInsertItem := `
+    insert into
+    calculated_estimate_items (
+      estimate_id,
+      merchant_id,
+      product_id,
+      is_starting_point,
+      quantity,
+      price
+    ) values (
+      $1, $2, $3, $4, $5, $6
+    );
+  `
+	for _, order := range estimate.Orders {
+		for _, item := range order.Items {
+			batch.Queue(
+				queryInsertItem,
+				estimate.ID,
+				order.MerchantID,
+				item.ProductID,
+				order.IsStartingPoint,
+				item.Quantity,
+				item.Price,
+			)
+		}
+	}
+
+	batchRes := tx.SendBatch(ctx, batch)
+	if err := batchRes.Close(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Code == "23503" {
+				return estimate, constant.ErrNotFound
+			}
+		}
+		return estimate, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return estimate, err
+	}
+
+	return estimate, nil
+}
+
+func (r *EstimateRepository) FindByID(
+	ctx context.Context,
+	estimate model.Estimate,
+) (model.Estimate, error) {
+	query := `
+    select
+      id,
+      user_id,
+      user_latitude,
+      user_longitude,
+      total_price,
+      estimated_delivery_time_in_minutes,
+      created_at
+    from calculated_estimates
+    where id = $1
+  `
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		estimate.ID,
+	).Scan(
+		&estimate.ID,
+		&estimate.UserID,
+		&estimate.UserLatitude,
+		&estimate.UserLongitude,
+		&estimate.TotalPrice,
+		&estimate.EstimatedDeliveryTimeInMinutes,
+		&estimate.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			pgx.ErrNoRows,
+		) {
+			return estimate, constant.ErrNotFound
+		}
+		return estimate, err
+	}
+
+	return estimate, nil
+}
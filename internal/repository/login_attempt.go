@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
+)
+
+type LoginAttemptRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLoginAttemptRepository(
+	db *pgxpool.Pool,
+) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+// FindLockedUntil returns the latest lockout of the username or the ip
+// that is still running at now, or nil when neither is locked.
+func (r *LoginAttemptRepository) FindLockedUntil(
+	ctx context.Context,
+	username string,
+	ipAddress string,
+	now time.Time,
+) (*time.Time, error) {
+	query := `
+    select max(locked_until)
+    from login_lockouts
+    where (
+      (scope = $1 and key = $2) or
+      (scope = $3 and key = $4)
+    ) and locked_until > $5
+  `
+	var lockedUntil *time.Time
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		model.LockoutScopeUsername,
+		username,
+		model.LockoutScopeIP,
+		ipAddress,
+		now,
+	).Scan(&lockedUntil)
+
+	return lockedUntil, err
+}
+
+// RecordFailure stores the attempt and bumps the failure counters of its
+// username and ip, restarting a counter whose last failure is older than
+// window. It returns both counters after the update.
+func (r *LoginAttemptRepository) RecordFailure(
+	ctx context.Context,
+	attempt model.LoginAttempt,
+	window time.Duration,
+) (int, int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	queryBumpCounter := `
+    insert into
+    login_lockouts (
+      scope,
+      key,
+      failed_count,
+      last_failed_at
+    ) values (
+      $1, $2, 1, $3
+    ) on conflict (scope, key) do update
+    set
+      failed_count = case
+        when login_lockouts.last_failed_at < $3 - $4::interval then 1
+        else login_lockouts.failed_count + 1
+      end,
+      last_failed_at = excluded.last_failed_at
+    returning failed_count
+  `
+	batch := &pgx.Batch{}
+	queueInsertAttempt(batch, attempt)
+	batch.Queue(
+		queryBumpCounter,
+		model.LockoutScopeUsername,
+		attempt.Username,
+		attempt.CreatedAt,
+		window,
+	)
+	batch.Queue(
+		queryBumpCounter,
+		model.LockoutScopeIP,
+		attempt.IPAddress,
+		attempt.CreatedAt,
+		window,
+	)
+
+	var usernameFailures, ipFailures int
+	results := tx.SendBatch(ctx, batch)
+	if _, err := results.Exec(); err != nil {
+		results.Close()
+		return 0, 0, err
+	}
+	if err := results.QueryRow().Scan(&usernameFailures); err != nil {
+		results.Close()
+		return 0, 0, err
+	}
+	if err := results.QueryRow().Scan(&ipFailures); err != nil {
+		results.Close()
+		return 0, 0, err
+	}
+	if err := results.Close(); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, err
+	}
+
+	return usernameFailures, ipFailures, nil
+}
+
+// RecordSuccess stores the attempt and clears the failures of its username.
+// The ip counter is left alone so one valid account cannot be used to keep
+// resetting it while guessing others.
+func (r *LoginAttemptRepository) RecordSuccess(
+	ctx context.Context,
+	attempt model.LoginAttempt,
+) error {
+	batch := &pgx.Batch{}
+	queueInsertAttempt(batch, attempt)
+	batch.Queue(
+		`delete from login_lockouts where scope = $1 and key = $2`,
+		model.LockoutScopeUsername,
+		attempt.Username,
+	)
+
+	return r.db.SendBatch(ctx, batch).Close()
+}
+
+func (r *LoginAttemptRepository) Lock(
+	ctx context.Context,
+	scope model.LockoutScope,
+	key string,
+	lockedUntil time.Time,
+) error {
+	query := `
+    update login_lockouts
+    set locked_until = $3
+    where scope = $1 and key = $2
+  `
+	_, err := r.db.Exec(ctx, query,
+		scope,
+		key,
+		lockedUntil,
+	)
+
+	return err
+}
+
+// Unlock clears the lockout and the failures of a username. It fails with
+// constant.ErrNotFound when the username has no recorded failures.
+func (r *LoginAttemptRepository) Unlock(
+	ctx context.Context,
+	username string,
+) error {
+	query := `
+    delete from login_lockouts
+    where scope = $1 and key = $2
+  `
+	tag, err := r.db.Exec(ctx, query,
+		model.LockoutScopeUsername,
+		username,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	return nil
+}
+
+func queueInsertAttempt(
+	batch *pgx.Batch,
+	attempt model.LoginAttempt,
+) {
+	batch.Queue(
+		`insert into
+    login_attempts (
+      id,
+      username,
+      ip_address,
+      succeeded,
+      created_at
+    ) values (
+      $1, $2, $3, $4, $5
+    )`,
+		attempt.ID,
+		attempt.Username,
+		attempt.IPAddress,
+		attempt.Succeeded,
+		attempt.CreatedAt,
+	)
+}
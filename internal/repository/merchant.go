@@ -1,23 +1,24 @@
 package repository
 
 import (
-	"bytes"
 	"context"
 	"errors"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nozzlium/belimang/internal/constant"
 	"github.com/nozzlium/belimang/internal/model"
-	"github.com/nozzlium/belimang/internal/util"
+	"github.com/nozzlium/belimang/internal/sqlbuilder"
 )
 
 type MerchantRepository struct {
-	db *pgx.Conn
+	db *pgxpool.Pool
 }
 
 func NewMerchantRepository(
-	db *pgx.Conn,
+	db *pgxpool.Pool,
 ) *MerchantRepository {
 	return &MerchantRepository{db: db}
 }
@@ -68,44 +69,112 @@ func (r *MerchantRepository) FindAll(
 	ctx context.Context,
 	merchantQueries model.MerchantQueries,
 ) ([]model.Merchant, int, error) {
-	var query bytes.Buffer
-	query.WriteString(`
-    select
-      id,
-      name, 
-      merchant_category,
-      image_url,
-      latitude,
-      longitude,
-      created_at
-    from merchants
-    where 1 = 1
-    `)
-	queries, params := util.BuildQueryStringAndParams(
-		&query,
-		merchantQueries.BuildWhereClauses,
-		merchantQueries.BuildPagination,
-		merchantQueries.BuildOrderByClause,
-		false,
+	query := merchantSelect().Where("deleted_at is null")
+	merchantQueries.Filter(query)
+	query.OrderBy(merchantQueries.Sorts...)
+
+	return r.findAll(
+		ctx,
+		query,
+		merchantQueries.Pagination,
+	)
+}
+
+func (r *MerchantRepository) FindTrashed(
+	ctx context.Context,
+	merchantQueries model.MerchantQueries,
+) ([]model.Merchant, int, error) {
+	query := merchantSelect().Where("deleted_at is not null")
+	merchantQueries.Filter(query)
+	query.OrderBy(merchantQueries.Sorts...)
+
+	return r.findAll(
+		ctx,
+		query,
+		merchantQueries.Pagination,
 	)
+}
 
-	var queryTotal bytes.Buffer
-	queryTotal.WriteString(`
-    select 
-    count(id)
-    from merchants
-    where 1 = 1
-    `)
-	queryTotalString, paramsTotal := util.BuildQueryStringAndParamsWithoutLimit(
-		&queryTotal,
-		merchantQueries.BuildWhereClauses,
-		nil,
+func (r *MerchantRepository) FindNearby(
+	ctx context.Context,
+	nearbyQueries model.MerchantNearbyQueries,
+) ([]model.Merchant, int, error) {
+	query := merchantSelect().Where("deleted_at is null")
+	nearbyQueries.Filter(query)
+	nearbyQueries.OrderByDistance(query)
+
+	return r.findAll(
+		ctx,
+		query,
+		nearbyQueries.Pagination,
+	)
+}
+
+// FindPage is FindAll with keyset pagination.
+func (r *MerchantRepository) FindPage(
+	ctx context.Context,
+	merchantQueries model.MerchantQueries,
+) (model.Page[model.Merchant], error) {
+	query := merchantSelect().Where("deleted_at is null")
+	merchantQueries.Filter(query)
+	limit, _ := merchantQueries.Pagination()
+
+	return findPage(
+		ctx,
+		r.db,
+		query,
+		merchantQueries.Sorts,
+		merchantQueries.CursorQueries,
+		limit,
+		scanMerchant,
+		model.Merchant.CursorValue,
+	)
+}
+
+func merchantSelect() *sqlbuilder.SelectBuilder {
+	return sqlbuilder.Select(
+		"id",
+		"name",
+		"merchant_category",
+		"image_url",
+		"latitude",
+		"longitude",
+		"created_at",
+		"deleted_at",
+	).From("merchants")
+}
+
+func scanMerchant(rows pgx.Rows) (model.Merchant, error) {
+	var merchant model.Merchant
+	err := rows.Scan(
+		&merchant.ID,
+		&merchant.Name,
+		&merchant.MerchantCategory,
+		&merchant.ImageURL,
+		&merchant.Latitude,
+		&merchant.Longitude,
+		&merchant.CreatedAt,
+		&merchant.DeletedAt,
 	)
+	return merchant, err
+}
+
+// findAll pages through the merchants the query selects and counts all of
+// them.
+func (r *MerchantRepository) findAll(
+	ctx context.Context,
+	query *sqlbuilder.SelectBuilder,
+	pagination func() (int, int),
+) ([]model.Merchant, int, error) {
+	queryTotal, paramsTotal := query.Count().Build()
+
+	limit, offset := pagination()
+	queries, params := query.Limit(limit).Offset(offset).Build()
 
 	batch := &pgx.Batch{}
 	batch.Queue(queries, params...)
 	batch.Queue(
-		queryTotalString,
+		queryTotal,
 		paramsTotal...)
 
 	br := r.db.SendBatch(ctx, batch)
@@ -125,11 +194,66 @@ func (r *MerchantRepository) FindAll(
 	merchants := make(
 		[]model.Merchant,
 		0,
-		merchantQueries.Limit,
+	)
+	for rows.Next() {
+		merchant, err := scanMerchant(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		merchants = append(
+			merchants,
+			merchant,
+		)
+	}
+
+	var total int
+	err = br.QueryRow().Scan(&total)
+	if err != nil {
+		if !errors.Is(
+			err,
+			pgx.ErrNoRows,
+		) {
+			return nil, 0, err
+		}
+	}
+
+	return merchants, total, nil
+}
+
+func (r *MerchantRepository) FindByIDs(
+	ctx context.Context,
+	merchantIDs []uuid.UUID,
+) ([]model.Merchant, error) {
+	query := `
+    select
+      id,
+      name,
+      merchant_category,
+      image_url,
+      latitude,
+      longitude,
+      created_at
+    from merchants
+    where id = any($1) and deleted_at is null
+  `
+	rows, err := r.db.Query(
+		ctx,
+		query,
+		merchantIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	merchants := make(
+		[]model.Merchant,
+		0,
+		len(merchantIDs),
 	)
 	for rows.Next() {
 		var merchant model.Merchant
-		rows.Scan(
+		err := rows.Scan(
 			&merchant.ID,
 			&merchant.Name,
 			&merchant.MerchantCategory,
@@ -138,22 +262,142 @@ func (r *MerchantRepository) FindAll(
 			&merchant.Longitude,
 			&merchant.CreatedAt,
 		)
+		if err != nil {
+			return nil, err
+		}
 		merchants = append(
 			merchants,
 			merchant,
 		)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	var total int
-	err = br.QueryRow().Scan(&total)
+	return merchants, nil
+}
+
+func (r *MerchantRepository) FindByIDAndUserID(
+	ctx context.Context,
+	merchant model.Merchant,
+) (model.Merchant, error) {
+	query := `
+    select
+      id,
+      user_id,
+      name,
+      merchant_category,
+      image_url,
+      latitude,
+      longitude,
+      created_at
+    from merchants
+    where id = $1 and user_id = $2 and deleted_at is null
+  `
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		merchant.ID,
+		merchant.UserID,
+	).Scan(
+		&merchant.ID,
+		&merchant.UserID,
+		&merchant.Name,
+		&merchant.MerchantCategory,
+		&merchant.ImageURL,
+		&merchant.Latitude,
+		&merchant.Longitude,
+		&merchant.CreatedAt,
+	)
 	if err != nil {
-		if !errors.Is(
+		if errors.Is(
 			err,
 			pgx.ErrNoRows,
 		) {
-			return nil, 0, err
+			return merchant, constant.ErrNotFound
 		}
+		return merchant, err
 	}
 
-	return merchants, total, nil
+	return merchant, nil
+}
+
+func (r *MerchantRepository) Update(
+	ctx context.Context,
+	merchant model.Merchant,
+) (model.Merchant, error) {
+	query := `
+    update merchants
+    set
+      name = $3,
+      merchant_category = $4,
+      image_url = $5,
+      latitude = $6,
+      longitude = $7
+    where id = $1 and user_id = $2 and deleted_at is null
+  `
+	tag, err := r.db.Exec(ctx, query,
+		merchant.ID,
+		merchant.UserID,
+		merchant.Name,
+		merchant.MerchantCategory,
+		merchant.ImageURL,
+		merchant.Latitude,
+		merchant.Longitude,
+	)
+	if err != nil {
+		return merchant, err
+	}
+	if tag.RowsAffected() == 0 {
+		return merchant, constant.ErrNotFound
+	}
+
+	return merchant, nil
+}
+
+func (r *MerchantRepository) SoftDelete(
+	ctx context.Context,
+	merchant model.Merchant,
+) error {
+	query := `
+    update merchants
+    set deleted_at = $3
+    where id = $1 and user_id = $2 and deleted_at is null
+  `
+	tag, err := r.db.Exec(ctx, query,
+		merchant.ID,
+		merchant.UserID,
+		merchant.DeletedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *MerchantRepository) Restore(
+	ctx context.Context,
+	merchant model.Merchant,
+) error {
+	query := `
+    update merchants
+    set deleted_at = null
+    where id = $1 and user_id = $2 and deleted_at is not null
+  `
+	tag, err := r.db.Exec(ctx, query,
+		merchant.ID,
+		merchant.UserID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	return nil
 }
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
+)
+
+type MFARepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMFARepository(
+	db *pgxpool.Pool,
+) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+func (r *MFARepository) FindByUserID(
+	ctx context.Context,
+	mfa model.MFA,
+) (model.MFA, error) {
+	query := `
+    select
+      secret,
+      confirmed_at,
+      last_used_step,
+      created_at
+    from user_mfa
+    where user_id = $1
+  `
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		mfa.UserID,
+	).Scan(
+		&mfa.Secret,
+		&mfa.ConfirmedAt,
+		&mfa.LastUsedStep,
+		&mfa.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			pgx.ErrNoRows,
+		) {
+			return mfa, constant.ErrNotFound
+		}
+		return mfa, err
+	}
+
+	return mfa, nil
+}
+
+// Enrol stores a new unconfirmed secret for the user, replacing an earlier
+// enrolment that was never confirmed. It fails with constant.ErrConflict
+// when the user already has two-factor authentication enabled.
+func (r *MFARepository) Enrol(
+	ctx context.Context,
+	mfa model.MFA,
+) error {
+	query := `
+    insert into
+    user_mfa (
+      user_id,
+      secret,
+      created_at
+    ) values (
+      $1, $2, $3
+    ) on conflict (user_id) do update
+    set
+      secret = excluded.secret,
+      last_used_step = 0,
+      created_at = excluded.created_at
+    where user_mfa.confirmed_at is null
+  `
+	tag, err := r.db.Exec(ctx, query,
+		mfa.UserID,
+		mfa.Secret,
+		mfa.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return constant.ErrConflict
+	}
+
+	return nil
+}
+
+// Confirm enables the enrolment and replaces the recovery codes of the
+// user with the given ones.
+func (r *MFARepository) Confirm(
+	ctx context.Context,
+	mfa model.MFA,
+	recoveryCodes []model.RecoveryCode,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	batch.Queue(
+		`update user_mfa
+    set confirmed_at = $2
+    where user_id = $1`,
+		mfa.UserID,
+		mfa.ConfirmedAt,
+	)
+	batch.Queue(
+		`delete from user_recovery_codes where user_id = $1`,
+		mfa.UserID,
+	)
+	for _, code := range recoveryCodes {
+		batch.Queue(
+			`insert into
+      user_recovery_codes (
+        id,
+        user_id,
+        code_hash,
+        created_at
+      ) values (
+        $1, $2, $3, $4
+      )`,
+			code.ID,
+			code.UserID,
+			code.CodeHash,
+			code.CreatedAt,
+		)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UseStep records step as the last accepted totp step of the user. It
+// returns false when a code of that step or a later one was already
+// accepted, so a code cannot be replayed.
+func (r *MFARepository) UseStep(
+	ctx context.Context,
+	mfa model.MFA,
+	step int64,
+) (bool, error) {
+	query := `
+    update user_mfa
+    set last_used_step = $2
+    where user_id = $1 and last_used_step < $2
+  `
+	tag, err := r.db.Exec(ctx, query,
+		mfa.UserID,
+		step,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode marks the unused recovery code with the given hash as
+// used. It returns false when the user has no such code left.
+func (r *MFARepository) UseRecoveryCode(
+	ctx context.Context,
+	code model.RecoveryCode,
+	usedAt time.Time,
+) (bool, error) {
+	query := `
+    update user_recovery_codes
+    set used_at = $3
+    where user_id = $1 and code_hash = $2 and used_at is null
+  `
+	tag, err := r.db.Exec(ctx, query,
+		code.UserID,
+		code.CodeHash,
+		usedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *MFARepository) FindSettings(
+	ctx context.Context,
+) (model.MFASettings, error) {
+	var settings model.MFASettings
+	err := r.db.QueryRow(
+		ctx,
+		`select required_for_admins from mfa_settings`,
+	).Scan(&settings.RequiredForAdmins)
+
+	return settings, err
+}
+
+func (r *MFARepository) UpdateSettings(
+	ctx context.Context,
+	settings model.MFASettings,
+	updatedAt time.Time,
+) error {
+	query := `
+    update mfa_settings
+    set
+      required_for_admins = $1,
+      updated_at = $2
+  `
+	_, err := r.db.Exec(ctx, query,
+		settings.RequiredForAdmins,
+		updatedAt,
+	)
+
+	return err
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/sqlbuilder"
+)
+
+type OrderRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOrderRepository(
+	db *pgxpool.Pool,
+) *OrderRepository {
+	return &OrderRepository{db: db}
+}
+
+func (r *OrderRepository) Insert(
+	ctx context.Context,
+	order model.Order,
+) (model.Order, error) {
+	query := `
+    insert into
+    orders (
+      id,
+      user_id,
+      estimate_id,
+      created_at
+    ) values (
+      $1, $2, $3, $4
+    );
+  `
+	_, err := r.db.Exec(ctx, query,
+		order.ID,
+		order.UserID,
+		order.EstimateID,
+		order.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return order, constant.ErrOrderExists
+			case "23503":
+				return order, constant.ErrNotFound
+			}
+		}
+		return order, err
+	}
+
+	return order, nil
+}
+
+// FindAll pages over the caller's orders that have at least one item
+// matching the filters, and returns every matching item of those orders
+// newest order first, grouped by merchant.
+func (r *OrderRepository) FindAll(
+	ctx context.Context,
+	queries model.OrderQueries,
+) ([]model.OrderItem, int, error) {
+	matchingItems := func(columns ...string) *sqlbuilder.SelectBuilder {
+		query := sqlbuilder.Select(columns...).From(
+			`orders o
+        inner join calculated_estimate_items ei on ei.estimate_id = o.estimate_id
+        inner join merchants m on m.id = ei.merchant_id
+        inner join products p on p.id = ei.product_id`,
+		)
+		queries.Filter(query)
+		return query
+	}
+
+	// orders are paged on their own so every order on a page comes with
+	// all of its matching items
+	orders := sqlbuilder.Select("o.id").From("orders o").WhereCond(
+		sqlbuilder.In("o.id", matchingItems("o.id")),
+	)
+	queryTotal, paramsTotal := orders.Count().Build()
+
+	limit, offset := queries.Pagination()
+	orders.OrderBy(
+		sqlbuilder.SortBy("o.created_at", sqlbuilder.Desc),
+		sqlbuilder.SortBy("o.id", sqlbuilder.Asc),
+	).Limit(limit).Offset(offset)
+
+	queryItems, paramsItems := matchingItems(
+		"o.id",
+		"m.id",
+		"m.name",
+		"m.merchant_category",
+		"m.image_url",
+		"m.latitude",
+		"m.longitude",
+		"m.created_at",
+		"p.id",
+		"p.name",
+		"p.product_category",
+		"ei.price",
+		"p.image_url",
+		"p.created_at",
+		"ei.quantity",
+	).WhereCond(
+		sqlbuilder.In("o.id", orders),
+	).OrderBy(
+		sqlbuilder.SortBy("o.created_at", sqlbuilder.Desc),
+		sqlbuilder.SortBy("o.id", sqlbuilder.Asc),
+		sqlbuilder.SortBy("m.id", sqlbuilder.Asc),
+		sqlbuilder.SortBy("p.created_at", sqlbuilder.Desc),
+	).Build()
+
+	batch := &pgx.Batch{}
+	batch.Queue(
+		queryItems,
+		paramsItems...)
+	batch.Queue(
+		queryTotal,
+		paramsTotal...)
+
+	br := r.db.SendBatch(ctx, batch)
+	defer br.Close()
+
+	rows, err := br.Query()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := make(
+		[]model.OrderItem,
+		0,
+	)
+	for rows.Next() {
+		var item model.OrderItem
+		err := rows.Scan(
+			&item.OrderID,
+			&item.Merchant.ID,
+			&item.Merchant.Name,
+			&item.Merchant.MerchantCategory,
+			&item.Merchant.ImageURL,
+			&item.Merchant.Latitude,
+			&item.Merchant.Longitude,
+			&item.Merchant.CreatedAt,
+			&item.Product.ID,
+			&item.Product.Name,
+			&item.Product.ProductCategory,
+			&item.Product.Price,
+			&item.Product.ImageURL,
+			&item.Product.CreatedAt,
+			&item.Quantity,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(
+			items,
+			item,
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = br.QueryRow().Scan(&total)
+	if err != nil {
+		if !errors.Is(
+			err,
+			pgx.ErrNoRows,
+		) {
+			return nil, 0, err
+		}
+	}
+
+	return items, total, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/sqlbuilder"
+)
+
+// findPage reads one page of the rows query selects, starting after the
+// cursor of paging when it has one. sorts has to end with a unique column.
+// The total counts the whole listing, not only what follows the cursor.
+// limit has to be checked against model.MaxLimit beforehand.
+func findPage[T any](
+	ctx context.Context,
+	db *pgxpool.Pool,
+	query *sqlbuilder.SelectBuilder,
+	sorts []sqlbuilder.Sort,
+	paging model.CursorQueries,
+	limit int,
+	scan func(rows pgx.Rows) (T, error),
+	value func(row T, column string) string,
+) (model.Page[T], error) {
+	var queryTotal string
+	var paramsTotal []any
+	switch paging.Total {
+	case model.TotalExact:
+		queryTotal, paramsTotal = query.Count().Build()
+	case model.TotalEstimate:
+		queryTotal, paramsTotal = query.Build()
+		queryTotal = "explain (format json) " + queryTotal
+	}
+
+	order := sorts
+	position := paging.Position()
+	if position != nil {
+		query.Seek(sorts, *position)
+		if position.Backward {
+			order = sqlbuilder.Reverse(sorts)
+		}
+	}
+	// one row more than asked for tells whether another page follows
+	queryItems, paramsItems := query.OrderBy(
+		order...,
+	).Limit(limit + 1).Build()
+
+	batch := &pgx.Batch{}
+	batch.Queue(
+		queryItems,
+		paramsItems...)
+	if queryTotal != "" {
+		batch.Queue(
+			queryTotal,
+			paramsTotal...)
+	}
+
+	br := db.SendBatch(ctx, batch)
+	defer br.Close()
+
+	rows, err := br.Query()
+	if err != nil {
+		return model.Page[T]{}, err
+	}
+	items := make(
+		[]T,
+		0,
+	)
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			rows.Close()
+			return model.Page[T]{}, err
+		}
+		items = append(
+			items,
+			item,
+		)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return model.Page[T]{}, err
+	}
+
+	var total *int
+	switch paging.Total {
+	case model.TotalExact:
+		var count int
+		if err := br.QueryRow().Scan(&count); err != nil {
+			return model.Page[T]{}, err
+		}
+		total = &count
+	case model.TotalEstimate:
+		count, err := estimateRows(br.QueryRow())
+		if err != nil {
+			return model.Page[T]{}, err
+		}
+		total = &count
+	}
+
+	items, next, prev := sqlbuilder.Page(
+		items,
+		limit,
+		sorts,
+		position,
+		value,
+	)
+
+	return model.Page[T]{
+		Items: items,
+		Next:  next,
+		Prev:  prev,
+		Total: total,
+	}, nil
+}
+
+// estimateRows reads the number of rows the planner expects from the
+// output of explain (format json), which costs no more than planning.
+func estimateRows(row pgx.Row) (int, error) {
+	var output []byte
+	if err := row.Scan(&output); err != nil {
+		return 0, err
+	}
+
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(output, &plans); err != nil {
+		return 0, err
+	}
+	if len(plans) == 0 {
+		return 0, nil
+	}
+
+	return int(plans[0].Plan.Rows), nil
+}
@@ -1,24 +1,25 @@
 package repository
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"log"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nozzlium/belimang/internal/constant"
 	"github.com/nozzlium/belimang/internal/model"
-	"github.com/nozzlium/belimang/internal/util"
+	"github.com/nozzlium/belimang/internal/sqlbuilder"
 )
 
 type ProductRepository struct {
-	db *pgx.Conn
+	db *pgxpool.Pool
 }
 
 func NewProductRepository(
-	db *pgx.Conn,
+	db *pgxpool.Pool,
 ) *ProductRepository {
 	return &ProductRepository{
 		db: db,
@@ -40,9 +41,10 @@ func (r *ProductRepository) Insert(
       price,
       product_category,
       image_url,
+      is_available,
       created_at
     ) values (
-      $1, $2, $3, $4, $5, $6, $7, $8
+      $1, $2, $3, $4, $5, $6, $7, $8, $9
     )
   `
 	_, err := r.db.Exec(ctx, query,
@@ -53,6 +55,7 @@ func (r *ProductRepository) Insert(
 		product.Price,
 		product.ProductCategory,
 		product.ImageURL,
+		product.IsAvailable,
 		product.CreatedAt,
 	)
 	if err != nil {
@@ -69,42 +72,65 @@ func (r *ProductRepository) Insert(
 	return nil
 }
 
-func (r *ProductRepository) FindAll(
+// FindPage is FindAll with keyset pagination.
+func (r *ProductRepository) FindPage(
 	ctx context.Context,
 	queries model.ProductQueries,
-) ([]model.Product, int, error) {
-	var queryItems bytes.Buffer
-	queryItems.WriteString(`
-    select
-      id,
-      name,
-      product_category,
-      price,
-      image_url,
-      created_at
-    from products
-    where 1 = 1
-    `)
-	queryItemsString, queryItemsParams := util.BuildQueryStringAndParams(
-		&queryItems,
-		queries.BuildWhereClauses,
-		queries.BuildPagination,
-		queries.BuildOrderByClause,
-		false,
+) (model.Page[model.Product], error) {
+	query := productSelect()
+	queries.Filter(query)
+	limit, _ := queries.Pagination()
+
+	return findPage(
+		ctx,
+		r.db,
+		query,
+		queries.Sorts,
+		queries.CursorQueries,
+		limit,
+		scanProduct,
+		model.Product.CursorValue,
 	)
+}
 
-	var queryTotal bytes.Buffer
-	queryTotal.WriteString(`
-    select
-      count(id)
-    from products
-    where 1 = 1
-    `)
-	queryTotalString, queryTotalParams := util.BuildQueryStringAndParamsWithoutLimit(
-		&queryTotal,
-		queries.BuildWhereClauses,
-		nil,
+func productSelect() *sqlbuilder.SelectBuilder {
+	return sqlbuilder.Select(
+		"id",
+		"name",
+		"product_category",
+		"price",
+		"image_url",
+		"is_available",
+		"created_at",
+	).From("products").Where("deleted_at is null")
+}
+
+func scanProduct(rows pgx.Rows) (model.Product, error) {
+	var product model.Product
+	err := rows.Scan(
+		&product.ID,
+		&product.Name,
+		&product.ProductCategory,
+		&product.Price,
+		&product.ImageURL,
+		&product.IsAvailable,
+		&product.CreatedAt,
 	)
+	return product, err
+}
+
+func (r *ProductRepository) FindAll(
+	ctx context.Context,
+	queries model.ProductQueries,
+) ([]model.Product, int, error) {
+	query := productSelect()
+	queries.Filter(query)
+	queryTotalString, queryTotalParams := query.Count().Build()
+
+	limit, offset := queries.Pagination()
+	queryItemsString, queryItemsParams := query.OrderBy(
+		queries.Sorts...,
+	).Limit(limit).Offset(offset).Build()
 
 	batch := &pgx.Batch{}
 	batch.Queue(
@@ -131,34 +157,253 @@ func (r *ProductRepository) FindAll(
 	products := make(
 		[]model.Product,
 		0,
-		queries.Limit,
+	)
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		products = append(
+			products,
+			product,
+		)
+	}
+
+	var total int
+	err = br.QueryRow().Scan(&total)
+	if err != nil {
+		if !errors.Is(
+			err,
+			pgx.ErrNoRows,
+		) {
+			return nil, 0, err
+		}
+	}
+
+	return products, total, nil
+}
+
+func (r *ProductRepository) FindByMerchantIDs(
+	ctx context.Context,
+	merchantIDs []uuid.UUID,
+) ([]model.Product, error) {
+	query := `
+    select
+      id,
+      merchant_id,
+      name,
+      product_category,
+      price,
+      image_url,
+      is_available,
+      created_at
+    from products
+    where merchant_id = any($1)
+      and is_available = true
+      and deleted_at is null
+    order by created_at desc
+  `
+	rows, err := r.db.Query(
+		ctx,
+		query,
+		merchantIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make(
+		[]model.Product,
+		0,
+		len(merchantIDs),
 	)
 	for rows.Next() {
 		var product model.Product
-		rows.Scan(
+		err := rows.Scan(
 			&product.ID,
+			&product.MerchantID,
 			&product.Name,
 			&product.ProductCategory,
 			&product.Price,
 			&product.ImageURL,
+			&product.IsAvailable,
 			&product.CreatedAt,
 		)
+		if err != nil {
+			return nil, err
+		}
 		products = append(
 			products,
 			product,
 		)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	var total int
-	err = br.QueryRow().Scan(&total)
+	return products, nil
+}
+
+func (r *ProductRepository) FindByIDs(
+	ctx context.Context,
+	productIDs []uuid.UUID,
+) ([]model.Product, error) {
+	query := `
+    select
+      id,
+      merchant_id,
+      name,
+      product_category,
+      price,
+      image_url,
+      is_available,
+      created_at
+    from products
+    where id = any($1) and deleted_at is null
+  `
+	rows, err := r.db.Query(
+		ctx,
+		query,
+		productIDs,
+	)
 	if err != nil {
-		if !errors.Is(
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make(
+		[]model.Product,
+		0,
+		len(productIDs),
+	)
+	for rows.Next() {
+		var product model.Product
+		err := rows.Scan(
+			&product.ID,
+			&product.MerchantID,
+			&product.Name,
+			&product.ProductCategory,
+			&product.Price,
+			&product.ImageURL,
+			&product.IsAvailable,
+			&product.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		products = append(
+			products,
+			product,
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (r *ProductRepository) FindByIDAndMerchantID(
+	ctx context.Context,
+	product model.Product,
+) (model.Product, error) {
+	query := `
+    select
+      id,
+      user_id,
+      merchant_id,
+      name,
+      product_category,
+      price,
+      image_url,
+      is_available,
+      created_at
+    from products
+    where id = $1 and merchant_id = $2 and deleted_at is null
+  `
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		product.ID,
+		product.MerchantID,
+	).Scan(
+		&product.ID,
+		&product.UserID,
+		&product.MerchantID,
+		&product.Name,
+		&product.ProductCategory,
+		&product.Price,
+		&product.ImageURL,
+		&product.IsAvailable,
+		&product.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(
 			err,
 			pgx.ErrNoRows,
 		) {
-			return nil, 0, err
+			return product, constant.ErrNotFound
 		}
+		return product, err
 	}
 
-	return products, total, nil
+	return product, nil
+}
+
+func (r *ProductRepository) Update(
+	ctx context.Context,
+	product model.Product,
+) (model.Product, error) {
+	query := `
+    update products
+    set
+      name = $3,
+      product_category = $4,
+      price = $5,
+      image_url = $6,
+      is_available = $7
+    where id = $1 and merchant_id = $2 and deleted_at is null
+  `
+	tag, err := r.db.Exec(ctx, query,
+		product.ID,
+		product.MerchantID,
+		product.Name,
+		product.ProductCategory,
+		product.Price,
+		product.ImageURL,
+		product.IsAvailable,
+	)
+	if err != nil {
+		return product, err
+	}
+	if tag.RowsAffected() == 0 {
+		return product, constant.ErrNotFound
+	}
+
+	return product, nil
+}
+
+func (r *ProductRepository) SoftDelete(
+	ctx context.Context,
+	product model.Product,
+) error {
+	query := `
+    update products
+    set deleted_at = $3
+    where id = $1 and merchant_id = $2 and deleted_at is null
+  `
+	tag, err := r.db.Exec(ctx, query,
+		product.ID,
+		product.MerchantID,
+		product.DeletedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
+
+	return nil
 }
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/sqlbuilder"
+)
+
+type SearchRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSearchRepository(
+	db *pgxpool.Pool,
+) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// Search finds merchants and available items by name with pg_trgm, best
+// matches first. A name matches when some stretch of it has a word
+// similarity of at least minSimilarity to the search, which tolerates
+// typos and partly typed words and is answered from the trigram indexes.
+func (r *SearchRepository) Search(
+	ctx context.Context,
+	queries model.SearchQueries,
+	minSimilarity float64,
+) ([]model.SearchHit, int, error) {
+	merchantHits := sqlbuilder.Select(
+		"'merchant' as type",
+		"m.id as merchant_id",
+		"m.name as merchant_name",
+		"m.merchant_category",
+		"m.image_url as merchant_image_url",
+		"m.latitude",
+		"m.longitude",
+		"m.created_at as merchant_created_at",
+		"null::uuid as item_id",
+		"null::varchar as item_name",
+		"null::product_category as product_category",
+		"null::numeric as price",
+		"null::varchar as item_image_url",
+		"null::boolean as is_available",
+		"null::timestamp as item_created_at",
+	).Column(
+		"word_similarity(?, m.name) as score",
+		queries.Query,
+	).From("merchants m").Where(
+		"m.deleted_at is null",
+	).Where(
+		"? <% m.name",
+		queries.Query,
+	)
+	queries.FilterMerchants(merchantHits)
+
+	itemHits := sqlbuilder.Select(
+		"'item'",
+		"m.id",
+		"m.name",
+		"m.merchant_category",
+		"m.image_url",
+		"m.latitude",
+		"m.longitude",
+		"m.created_at",
+		"p.id",
+		"p.name",
+		"p.product_category",
+		"p.price",
+		"p.image_url",
+		"p.is_available",
+		"p.created_at",
+	).Column(
+		"word_similarity(?, p.name)",
+		queries.Query,
+	).From(
+		"products p join merchants m on m.id = p.merchant_id",
+	).Where(
+		"p.deleted_at is null and p.is_available",
+	).Where(
+		"m.deleted_at is null",
+	).Where(
+		"? <% p.name",
+		queries.Query,
+	)
+	queries.FilterItems(itemHits)
+
+	query := sqlbuilder.Select(
+		"type",
+		"merchant_id",
+		"merchant_name",
+		"merchant_category",
+		"merchant_image_url",
+		"latitude",
+		"longitude",
+		"merchant_created_at",
+		"item_id",
+		"item_name",
+		"product_category",
+		"price",
+		"item_image_url",
+		"is_available",
+		"item_created_at",
+		"score",
+	).FromQuery(
+		sqlbuilder.UnionAll(merchantHits, itemHits),
+		"hits",
+	)
+	queryTotal, paramsTotal := query.Count().Build()
+
+	limit, offset := queries.Pagination()
+	queryHits, paramsHits := query.OrderBy(
+		sqlbuilder.SortBy("score", sqlbuilder.Desc),
+		sqlbuilder.SortBy("merchant_id", sqlbuilder.Desc),
+	).OrderByExpr(
+		"item_id desc nulls first",
+	).Limit(limit).Offset(offset).Build()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// <% matches by this threshold, set for the transaction only
+	batch := &pgx.Batch{}
+	batch.Queue(
+		"select set_config('pg_trgm.word_similarity_threshold', $1, true)",
+		strconv.FormatFloat(minSimilarity, 'f', -1, 64),
+	)
+	batch.Queue(
+		queryHits,
+		paramsHits...)
+	batch.Queue(
+		queryTotal,
+		paramsTotal...)
+
+	br := tx.SendBatch(ctx, batch)
+	defer br.Close()
+
+	if _, err := br.Exec(); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := br.Query()
+	if err != nil {
+		return nil, 0, err
+	}
+	hits := make(
+		[]model.SearchHit,
+		0,
+	)
+	for rows.Next() {
+		hit, err := scanSearchHit(rows)
+		if err != nil {
+			rows.Close()
+			return nil, 0, err
+		}
+		hits = append(
+			hits,
+			hit,
+		)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := br.QueryRow().Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return hits, total, nil
+}
+
+func scanSearchHit(rows pgx.Rows) (model.SearchHit, error) {
+	var hit model.SearchHit
+	var itemID *uuid.UUID
+	var itemName *string
+	var productCategory *model.ProductCategory
+	var price *float64
+	var itemImageURL *string
+	var isAvailable *bool
+	var itemCreatedAt *time.Time
+	err := rows.Scan(
+		&hit.Type,
+		&hit.Merchant.ID,
+		&hit.Merchant.Name,
+		&hit.Merchant.MerchantCategory,
+		&hit.Merchant.ImageURL,
+		&hit.Merchant.Latitude,
+		&hit.Merchant.Longitude,
+		&hit.Merchant.CreatedAt,
+		&itemID,
+		&itemName,
+		&productCategory,
+		&price,
+		&itemImageURL,
+		&isAvailable,
+		&itemCreatedAt,
+		&hit.Score,
+	)
+	if err != nil {
+		return model.SearchHit{}, err
+	}
+
+	if itemID != nil {
+		hit.Item = &model.Product{
+			ID:              *itemID,
+			MerchantID:      hit.Merchant.ID,
+			Name:            *itemName,
+			Price:           *price,
+			ProductCategory: *productCategory,
+			ImageURL:        *itemImageURL,
+			IsAvailable:     *isAvailable,
+			CreatedAt:       *itemCreatedAt,
+		}
+	}
+
+	return hit, nil
+}
@@ -0,0 +1,441 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
+)
+
+type TokenRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTokenRepository(
+	db *pgxpool.Pool,
+) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+func (r *TokenRepository) InsertRefreshToken(
+	ctx context.Context,
+	token model.RefreshToken,
+) error {
+	query := `
+    insert into
+    refresh_tokens (
+      id,
+      user_id,
+      family_id,
+      token_hash,
+      expires_at,
+      created_at
+    ) values (
+      $1, $2, $3, $4, $5, $6
+    );
+  `
+	_, err := r.db.Exec(ctx, query,
+		token.ID,
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+
+	return err
+}
+
+func (r *TokenRepository) FindRefreshTokenByHash(
+	ctx context.Context,
+	token model.RefreshToken,
+) (model.RefreshToken, error) {
+	query := `
+    select
+      id,
+      user_id,
+      family_id,
+      token_hash,
+      expires_at,
+      revoked_at,
+      created_at
+    from refresh_tokens
+    where token_hash = $1
+  `
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		token.TokenHash,
+	).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			pgx.ErrNoRows,
+		) {
+			return token, constant.ErrNotFound
+		}
+		return token, err
+	}
+
+	return token, nil
+}
+
+// RotateRefreshToken revokes the old token and stores its replacement in a
+// single transaction. It fails with constant.ErrUnauthorized when the old
+// token was already revoked by a concurrent rotation.
+func (r *TokenRepository) RotateRefreshToken(
+	ctx context.Context,
+	oldToken model.RefreshToken,
+	newToken model.RefreshToken,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(
+		ctx,
+		`update refresh_tokens
+    set revoked_at = $2
+    where id = $1 and revoked_at is null`,
+		oldToken.ID,
+		newToken.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return constant.ErrUnauthorized
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`insert into
+    refresh_tokens (
+      id,
+      user_id,
+      family_id,
+      token_hash,
+      expires_at,
+      created_at
+    ) values (
+      $1, $2, $3, $4, $5, $6
+    )`,
+		newToken.ID,
+		newToken.UserID,
+		newToken.FamilyID,
+		newToken.TokenHash,
+		newToken.ExpiresAt,
+		newToken.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *TokenRepository) RevokeFamily(
+	ctx context.Context,
+	familyID uuid.UUID,
+	revokedAt time.Time,
+) error {
+	query := `
+    update refresh_tokens
+    set revoked_at = $2
+    where family_id = $1 and revoked_at is null
+  `
+	_, err := r.db.Exec(ctx, query,
+		familyID,
+		revokedAt,
+	)
+
+	return err
+}
+
+// RevokeOtherFamilies revokes the refresh tokens of every session of the
+// user except keepFamilyID.
+func (r *TokenRepository) RevokeOtherFamilies(
+	ctx context.Context,
+	userID uuid.UUID,
+	keepFamilyID uuid.UUID,
+	revokedAt time.Time,
+) error {
+	query := `
+    update refresh_tokens
+    set revoked_at = $3
+    where user_id = $1 and family_id <> $2 and revoked_at is null
+  `
+	_, err := r.db.Exec(ctx, query,
+		userID,
+		keepFamilyID,
+		revokedAt,
+	)
+
+	return err
+}
+
+func (r *TokenRepository) RevokeAccessToken(
+	ctx context.Context,
+	tokenID uuid.UUID,
+	expiresAt time.Time,
+) error {
+	batch := &pgx.Batch{}
+	batch.Queue(
+		`delete from revoked_access_tokens where expires_at < now()`,
+	)
+	batch.Queue(
+		`insert into
+    revoked_access_tokens (
+      jti,
+      expires_at
+    ) values (
+      $1, $2
+    ) on conflict (jti) do nothing`,
+		tokenID,
+		expiresAt,
+	)
+
+	return r.db.SendBatch(ctx, batch).Close()
+}
+
+// RevokeAllForUser revokes every refresh token of the user and rejects all
+// access tokens issued up to revokedBefore.
+func (r *TokenRepository) RevokeAllForUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	revokedBefore time.Time,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	queueRevokeAllForUser(
+		batch,
+		userID,
+		revokedBefore,
+	)
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// queueRevokeAllForUser lets other repositories revoke the sessions of a
+// user in the same transaction as their own changes. Access tokens only
+// carry whole seconds in iat, so the cutoff is cut down to the second and
+// tokens issued later in that second, such as from a login right after,
+// stay valid.
+func queueRevokeAllForUser(
+	batch *pgx.Batch,
+	userID uuid.UUID,
+	revokedBefore time.Time,
+) {
+	batch.Queue(
+		`update refresh_tokens
+    set revoked_at = $2
+    where user_id = $1 and revoked_at is null`,
+		userID,
+		revokedBefore,
+	)
+	batch.Queue(
+		`insert into
+    user_token_revocations (
+      user_id,
+      revoked_before
+    ) values (
+      $1, $2
+    ) on conflict (user_id) do update
+    set revoked_before = excluded.revoked_before`,
+		userID,
+		revokedBefore.Truncate(time.Second),
+	)
+}
+
+func (r *TokenRepository) IsAccessTokenRevoked(
+	ctx context.Context,
+	tokenID uuid.UUID,
+	userID uuid.UUID,
+	issuedAt time.Time,
+) (bool, error) {
+	query := `
+    select
+      exists (
+        select 1 from revoked_access_tokens where jti = $1
+      ) or exists (
+        select 1 from user_token_revocations
+        where user_id = $2 and revoked_before > $3
+      )
+  `
+	var revoked bool
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		tokenID,
+		userID,
+		issuedAt,
+	).Scan(&revoked)
+
+	return revoked, err
+}
+
+// InsertActionToken stores the token and retires every unused token of the
+// same purpose issued to the user before, so only the latest mail works.
+func (r *TokenRepository) InsertActionToken(
+	ctx context.Context,
+	token model.ActionToken,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	batch.Queue(
+		`update user_action_tokens
+    set used_at = $3
+    where user_id = $1 and purpose = $2 and used_at is null`,
+		token.UserID,
+		token.Purpose,
+		token.CreatedAt,
+	)
+	batch.Queue(
+		`insert into
+    user_action_tokens (
+      id,
+      user_id,
+      purpose,
+      token_hash,
+      expires_at,
+      created_at
+    ) values (
+      $1, $2, $3, $4, $5, $6
+    )`,
+		token.ID,
+		token.UserID,
+		token.Purpose,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ConsumeActionToken marks an unused, unexpired token with the given hash
+// and purpose as used and returns it. Concurrent calls with the same token
+// succeed at most once; the others get constant.ErrNotFound.
+func (r *TokenRepository) ConsumeActionToken(
+	ctx context.Context,
+	token model.ActionToken,
+	usedAt time.Time,
+) (model.ActionToken, error) {
+	query := `
+    update user_action_tokens
+    set used_at = $3
+    where
+      token_hash = $1 and
+      purpose = $2 and
+      used_at is null and
+      expires_at > $3
+    returning
+      id,
+      user_id,
+      expires_at,
+      used_at,
+      created_at
+  `
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		token.TokenHash,
+		token.Purpose,
+		usedAt,
+	).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			pgx.ErrNoRows,
+		) {
+			return token, constant.ErrNotFound
+		}
+		return token, err
+	}
+
+	return token, nil
+}
+
+// FindActionToken returns the unused, unexpired token with the given hash
+// and purpose without using it up, or constant.ErrNotFound.
+func (r *TokenRepository) FindActionToken(
+	ctx context.Context,
+	token model.ActionToken,
+	now time.Time,
+) (model.ActionToken, error) {
+	query := `
+    select
+      id,
+      user_id,
+      expires_at,
+      used_at,
+      created_at
+    from user_action_tokens
+    where
+      token_hash = $1 and
+      purpose = $2 and
+      used_at is null and
+      expires_at > $3
+  `
+	err := r.db.QueryRow(
+		ctx,
+		query,
+		token.TokenHash,
+		token.Purpose,
+		now,
+	).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			pgx.ErrNoRows,
+		) {
+			return token, constant.ErrNotFound
+		}
+		return token, err
+	}
+
+	return token, nil
+}
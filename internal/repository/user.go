@@ -3,24 +3,43 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nozzlium/belimang/internal/constant"
 	"github.com/nozzlium/belimang/internal/model"
 )
 
+const queryFindUser = `
+    select
+      u.id,
+      u.username,
+      u.email,
+      u.password,
+      u.email_verified_at,
+      array(
+        select r.role from user_roles r
+        where r.user_id = u.id
+        order by r.role
+      )
+    from users u
+  `
+
 type UserRepository struct {
-	db *pgx.Conn
+	db *pgxpool.Pool
 }
 
 func NewUserRepository(
-	db *pgx.Conn,
+	db *pgxpool.Pool,
 ) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-func (r *UserRepository) CreateAdmin(
+// Create stores a new account along with its roles. A username or email
+// that is already taken fails with constant.ErrConflict.
+func (r *UserRepository) Create(
 	ctx context.Context,
 	user model.User,
 ) (model.User, error) {
@@ -36,33 +55,27 @@ func (r *UserRepository) CreateAdmin(
     insert into
     users (
       id,
-      username
-    ) values (
-      $1, $2
-    );
-  `
-	batch.Queue(
-		queryInsertUser,
-		user.ID,
-		user.Username,
-	)
-
-	queryInsertUsername := `
-    insert into
-    admin_details (
-      user_id,
+      username,
       email,
       password
     ) values (
-      $1, $2, $3
+      $1, $2, $3, $4
     );
   `
 	batch.Queue(
-		queryInsertUsername,
+		queryInsertUser,
 		user.ID,
+		user.Username,
 		user.Email,
 		user.Password,
 	)
+	for _, role := range user.Roles {
+		batch.Queue(
+			`insert into user_roles (user_id, role) values ($1, $2)`,
+			user.ID,
+			role,
+		)
+	}
 
 	batchRes := tx.SendBatch(ctx, batch)
 	if err := batchRes.Close(); err != nil {
@@ -82,123 +95,235 @@ func (r *UserRepository) CreateAdmin(
 	return user, nil
 }
 
-func (r *UserRepository) CreateUser(
+// AddRole grants the role to the account, doing nothing when it already
+// holds it.
+func (r *UserRepository) AddRole(
+	ctx context.Context,
+	user model.User,
+	role model.Role,
+	grantedAt time.Time,
+) error {
+	query := `
+    insert into
+    user_roles (
+      user_id,
+      role,
+      created_at
+    ) values (
+      $1, $2, $3
+    ) on conflict (user_id, role) do nothing
+  `
+	_, err := r.db.Exec(ctx, query,
+		user.ID,
+		role,
+		grantedAt,
+	)
+
+	return err
+}
+
+func (r *UserRepository) RemoveRole(
+	ctx context.Context,
+	user model.User,
+	role model.Role,
+) error {
+	query := `
+    delete from user_roles
+    where user_id = $1 and role = $2
+  `
+	_, err := r.db.Exec(ctx, query,
+		user.ID,
+		role,
+	)
+
+	return err
+}
+
+func (r *UserRepository) FindByUsername(
 	ctx context.Context,
 	user model.User,
 ) (model.User, error) {
-	tx, err := r.db.Begin(ctx)
+	return r.findOne(
+		ctx,
+		queryFindUser+`where lower(u.username) = lower($1)`,
+		user.Username,
+	)
+}
+
+func (r *UserRepository) FindByID(
+	ctx context.Context,
+	user model.User,
+) (model.User, error) {
+	return r.findOne(
+		ctx,
+		queryFindUser+`where u.id = $1`,
+		user.ID,
+	)
+}
+
+func (r *UserRepository) FindByEmail(
+	ctx context.Context,
+	user model.User,
+) (model.User, error) {
+	return r.findOne(
+		ctx,
+		queryFindUser+`where lower(u.email) = lower($1)`,
+		user.Email,
+	)
+}
+
+func (r *UserRepository) UpdatePassword(
+	ctx context.Context,
+	user model.User,
+) error {
+	query := `
+    update users
+    set password = $2
+    where id = $1
+  `
+	tag, err := r.db.Exec(ctx, query,
+		user.ID,
+		user.Password,
+	)
 	if err != nil {
-		return user, err
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
 	}
-	defer tx.Rollback(ctx)
 
-	batch := &pgx.Batch{}
+	return nil
+}
 
-	queryInsertUser := `
-    insert into
-    users (
-      id,
-      username
-    ) values (
-      $1, $2
-    );
+func (r *UserRepository) MarkEmailVerified(
+	ctx context.Context,
+	user model.User,
+) error {
+	query := `
+    update users
+    set email_verified_at = coalesce(email_verified_at, $2)
+    where id = $1
   `
-	batch.Queue(
-		queryInsertUser,
+	tag, err := r.db.Exec(ctx, query,
 		user.ID,
-		user.Username,
+		user.EmailVerifiedAt,
 	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
+	}
 
-	queryInsertUsername := `
-    insert into
-    user_details (
-      user_id,
-      email,
-      password
-    ) values (
-      $1, $2, $3
-    );
-  `
+	return nil
+}
+
+// ResetPassword sets a new password, marks the email verified since the
+// reset link proved access to it and revokes every session of the user,
+// all in one transaction so no session outlives the old password.
+func (r *UserRepository) ResetPassword(
+	ctx context.Context,
+	user model.User,
+	revokedBefore time.Time,
+) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
 	batch.Queue(
-		queryInsertUsername,
+		`update users
+    set
+      password = $2,
+      email_verified_at = coalesce(email_verified_at, $3)
+    where id = $1`,
 		user.ID,
-		user.Email,
 		user.Password,
+		user.EmailVerifiedAt,
+	)
+	queueRevokeAllForUser(
+		batch,
+		user.ID,
+		revokedBefore,
 	)
 
-	batchRes := tx.SendBatch(ctx, batch)
-	if err := batchRes.Close(); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Code == "23505" {
-				return user, constant.ErrConflict
-			}
-		}
-		return user, err
+	br := tx.SendBatch(ctx, batch)
+	tag, err := br.Exec()
+	if err != nil {
+		br.Close()
+		return err
 	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return user, err
+	if tag.RowsAffected() == 0 {
+		br.Close()
+		return constant.ErrNotFound
+	}
+	if err := br.Close(); err != nil {
+		return err
 	}
 
-	return user, nil
+	return tx.Commit(ctx)
 }
 
-func (r *UserRepository) FindAdminByUsername(
+// Update saves the username, email, password and verification time of the
+// user. A username or email taken by another account fails with
+// constant.ErrConflict.
+func (r *UserRepository) Update(
 	ctx context.Context,
 	user model.User,
-) (model.User, error) {
-	queryFindUser := `
-    select
-      u.id,
-      u.username,
-      ad.email,
-      ad.password
-    from 
-      users u 
-      inner join admin_details ad on u.id = ad.user_id 
-    where u.username = $1
+) error {
+	query := `
+    update users
+    set
+      username = $2,
+      email = $3,
+      password = $4,
+      email_verified_at = $5
+    where id = $1
   `
-
-	err := r.db.QueryRow(
-		ctx,
-		queryFindUser,
+	tag, err := r.db.Exec(ctx, query,
+		user.ID,
 		user.Username,
-	).Scan(&user.ID, &user.Username, &user.Email, &user.Password)
+		user.Email,
+		user.Password,
+		user.EmailVerifiedAt,
+	)
 	if err != nil {
-		if errors.Is(
-			err,
-			pgx.ErrNoRows,
-		) {
-			return user, constant.ErrNotFound
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Code == "23505" {
+				return constant.ErrConflict
+			}
 		}
-		return user, err
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return constant.ErrNotFound
 	}
 
-	return user, nil
+	return nil
 }
 
-func (r *UserRepository) FindUserByUsername(
+func (r *UserRepository) findOne(
 	ctx context.Context,
-	user model.User,
+	query string,
+	args ...interface{},
 ) (model.User, error) {
-	queryFindUser := `
-    select
-      u.id,
-      u.username,
-      ud.email,
-      ud.password
-    from 
-      users u 
-      inner join user_details ud on u.id = ud.user_id 
-    where u.username = $1
-  `
-
+	var user model.User
+	var roles []string
 	err := r.db.QueryRow(
 		ctx,
-		queryFindUser,
-		user.Username,
-	).Scan(&user.ID, &user.Username, &user.Email, &user.Password)
+		query,
+		args...,
+	).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Password,
+		&user.EmailVerifiedAt,
+		&roles,
+	)
 	if err != nil {
 		if errors.Is(
 			err,
@@ -209,5 +334,13 @@ func (r *UserRepository) FindUserByUsername(
 		return user, err
 	}
 
+	user.Roles = make([]model.Role, 0, len(roles))
+	for _, role := range roles {
+		user.Roles = append(
+			user.Roles,
+			model.Role(role),
+		)
+	}
+
 	return user, nil
 }
@@ -0,0 +1,222 @@
+package router
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/nozzlium/belimang/internal/config"
+	"github.com/nozzlium/belimang/internal/handler"
+	"github.com/nozzlium/belimang/internal/middleware"
+	"github.com/nozzlium/belimang/internal/model"
+)
+
+type Handlers struct {
+	User     *handler.UserHandler
+	Merchant *handler.MerchantHandler
+	Product  *handler.ProductHandler
+	Estimate *handler.EstimateHandler
+	Order    *handler.OrderHandler
+	Search   *handler.SearchHandler
+}
+
+// Register mounts every route of the api on app, with the authentication
+// and roles each route group requires.
+func Register(
+	app *fiber.App,
+	authConfig config.AuthConfig,
+	revocationChecker middleware.RevocationChecker,
+	handlers Handlers,
+) {
+	admin := app.Group("/admin")
+	admin.Post(
+		"/register",
+		handlers.User.RegisterAdmin,
+	)
+	admin.Post(
+		"/login",
+		handlers.User.LoginAdmin,
+	)
+	adminProtected := admin.Use(
+		middleware.Protected(authConfig, revocationChecker),
+	).Use(middleware.RequireRole(model.RoleAdmin))
+	adminProtected.Get(
+		"/me",
+		handlers.User.FindMe,
+	)
+	adminProtected.Patch(
+		"/me",
+		handlers.User.UpdateMe,
+	)
+	// anyone can register an admin, so lifting lockouts, which would let
+	// them keep guessing any password, is left to operators
+	adminProtected.Post(
+		"/users/:username/unlock",
+		middleware.RequireRole(model.RoleOperator),
+		handlers.User.Unlock,
+	)
+	adminProtected.Get(
+		"/settings/mfa",
+		handlers.User.FindMFASettings,
+	)
+	// the policy covers every admin of the deployment, so tenant admins
+	// may read it but only operators change it
+	adminProtected.Put(
+		"/settings/mfa",
+		middleware.RequireRole(model.RoleOperator),
+		handlers.User.UpdateMFASettings,
+	)
+	adminProtected.Post(
+		"/merchants",
+		handlers.Merchant.Create,
+	)
+	adminProtected.Get(
+		"/merchants",
+		handlers.Merchant.FindAll,
+	)
+	adminProtected.Get(
+		"/merchants/trash",
+		handlers.Merchant.FindTrashed,
+	)
+	adminProtected.Patch(
+		"/merchants/:merchantId",
+		handlers.Merchant.Update,
+	)
+	adminProtected.Delete(
+		"/merchants/:merchantId",
+		handlers.Merchant.Delete,
+	)
+	adminProtected.Post(
+		"/merchants/:merchantId/restore",
+		handlers.Merchant.Restore,
+	)
+	adminProtected.Post(
+		"/merchants/:merchantId/items",
+		handlers.Product.Create,
+	)
+	adminProtected.Get(
+		"/merchants/:merchantId/items",
+		handlers.Product.FindAll,
+	)
+	adminProtected.Patch(
+		"/merchants/:merchantId/items/:itemId",
+		handlers.Product.Update,
+	)
+	adminProtected.Delete(
+		"/merchants/:merchantId/items/:itemId",
+		handlers.Product.Delete,
+	)
+
+	user := app.Group("/user")
+	user.Post(
+		"/register",
+		handlers.User.RegisterUser,
+	)
+	user.Post(
+		"/login",
+		handlers.User.LoginUser,
+	)
+	// mounted on /user/me alone, since middleware used on /user would
+	// also run for every /users route.
+	userMe := user.Group(
+		"/me",
+		middleware.Protected(authConfig, revocationChecker),
+		middleware.RequireRole(model.RoleUser),
+	)
+	userMe.Get(
+		"",
+		handlers.User.FindMe,
+	)
+	userMe.Patch(
+		"",
+		handlers.User.UpdateMe,
+	)
+
+	app.Get(
+		"/.well-known/jwks.json",
+		handlers.User.JWKS,
+	)
+
+	auth := app.Group("/auth")
+	auth.Post(
+		"/refresh",
+		handlers.User.Refresh,
+	)
+	auth.Post(
+		"/verify-email",
+		handlers.User.VerifyEmail,
+	)
+	auth.Post(
+		"/password/forgot",
+		handlers.User.ForgotPassword,
+	)
+	auth.Post(
+		"/password/reset",
+		handlers.User.ResetPassword,
+	)
+	auth.Post(
+		"/mfa/verify",
+		handlers.User.VerifyMFA,
+	)
+	auth.Post(
+		"/mfa/pending/enrol",
+		handlers.User.EnrolPendingMFA,
+	)
+	authProtected := auth.Use(
+		middleware.Protected(authConfig, revocationChecker),
+	)
+	authProtected.Post(
+		"/roles",
+		handlers.User.AddRole,
+	)
+	authProtected.Post(
+		"/verify-email/resend",
+		handlers.User.ResendVerificationEmail,
+	)
+	authProtected.Post(
+		"/logout",
+		handlers.User.Logout,
+	)
+	authProtected.Post(
+		"/logout-all",
+		handlers.User.LogoutAll,
+	)
+	authProtected.Post(
+		"/mfa/enrol",
+		handlers.User.EnrolMFA,
+	)
+	authProtected.Post(
+		"/mfa/confirm",
+		handlers.User.ConfirmMFA,
+	)
+
+	merchants := app.Group("/merchants")
+	merchantsProtected := merchants.Use(
+		middleware.Protected(authConfig, revocationChecker),
+	).Use(middleware.RequireRole(model.RoleUser))
+	merchantsProtected.Get(
+		"/nearby/:coordinates",
+		handlers.Merchant.FindNearby,
+	)
+
+	app.Get(
+		"/search",
+		middleware.Protected(authConfig, revocationChecker),
+		middleware.RequireRole(model.RoleUser),
+		handlers.Search.Search,
+	)
+
+	users := app.Group("/users")
+	usersProtected := users.Use(
+		middleware.Protected(authConfig, revocationChecker),
+	).Use(middleware.RequireRole(model.RoleUser))
+	usersProtected.Post(
+		"/estimate",
+		handlers.Estimate.Estimate,
+	)
+	usersProtected.Post(
+		"/orders",
+		handlers.Order.Create,
+	)
+	usersProtected.Get(
+		"/orders",
+		handlers.Order.FindAll,
+	)
+}
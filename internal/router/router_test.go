@@ -0,0 +1,216 @@
+package router_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/config"
+	"github.com/nozzlium/belimang/internal/handler"
+	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/router"
+)
+
+var testAuthConfig = config.AuthConfig{
+	SigningMethod:  jwt.SigningMethodHS256,
+	SigningKey:     []byte("test-secret-test-secret-test-secret"),
+	JWTSecret:      []byte("test-secret-test-secret-test-secret"),
+	AccessTokenTTL: time.Minute,
+	Issuer:         "belimang",
+	Audience:       "belimang",
+}
+
+type notRevoked struct{}
+
+func (notRevoked) IsRevoked(
+	ctx context.Context,
+	tokenID uuid.UUID,
+	userID uuid.UUID,
+	issuedAt time.Time,
+) (bool, error) {
+	return false, nil
+}
+
+// newTestApp registers the production routes with handlers that have no
+// services. Requests the middleware lets through panic in the handler and
+// answer 500, which tells them apart from 401 and 403.
+func newTestApp() *fiber.App {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: handler.ErrorHandler,
+	})
+	app.Use(recover.New())
+	router.Register(
+		app,
+		testAuthConfig,
+		notRevoked{},
+		router.Handlers{
+			User:     handler.NewUserHandler(nil),
+			Merchant: handler.NewMerchantHandler(nil),
+			Product:  handler.NewProductHandler(nil),
+			Estimate: handler.NewEstimateHandler(nil),
+			Order:    handler.NewOrderHandler(nil),
+			Search:   handler.NewSearchHandler(nil),
+		},
+	)
+
+	return app
+}
+
+func bearer(
+	t *testing.T,
+	roles ...model.Role,
+) string {
+	t.Helper()
+	now := time.Now()
+	claims := model.NewAccessTokenClaims(
+		model.User{
+			ID:       uuid.New(),
+			Username: "tester",
+			Email:    "tester@example.com",
+			Roles:    roles,
+		},
+		uuid.New(),
+		uuid.New(),
+	)
+	claims.Issuer = testAuthConfig.Issuer
+	claims.Audience = jwt.ClaimStrings{testAuthConfig.Audience}
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(time.Minute))
+
+	token, err := jwt.NewWithClaims(
+		jwt.SigningMethodHS256,
+		claims,
+	).SignedString(testAuthConfig.SigningKey)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return "Bearer " + token
+}
+
+func requestStatus(
+	t *testing.T,
+	app *fiber.App,
+	method string,
+	path string,
+	authorization string,
+) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	if authorization != "" {
+		req.Header.Set(fiber.HeaderAuthorization, authorization)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	return resp.StatusCode
+}
+
+type route struct {
+	method string
+	path   string
+}
+
+var (
+	anyAccount = []string{"user", "admin", "operator"}
+	users      = []string{"user"}
+	admins     = []string{"admin", "operator"}
+	operators  = []string{"operator"}
+)
+
+func TestProtectedRoutesRequireTheirRole(t *testing.T) {
+	app := newTestApp()
+	tokens := map[string]string{
+		"user":     bearer(t, model.RoleUser),
+		"admin":    bearer(t, model.RoleAdmin),
+		"operator": bearer(t, model.RoleAdmin, model.RoleOperator),
+	}
+
+	tests := []struct {
+		route   route
+		allowed []string
+	}{
+		{route{fiber.MethodGet, "/admin/me"}, admins},
+		{route{fiber.MethodPatch, "/admin/me"}, admins},
+		{route{fiber.MethodGet, "/admin/settings/mfa"}, admins},
+		{route{fiber.MethodPut, "/admin/settings/mfa"}, operators},
+		{route{fiber.MethodPost, "/admin/users/tester/unlock"}, operators},
+		{route{fiber.MethodPost, "/admin/merchants"}, admins},
+		{route{fiber.MethodGet, "/admin/merchants"}, admins},
+		{route{fiber.MethodGet, "/admin/merchants/trash"}, admins},
+		{route{fiber.MethodPatch, "/admin/merchants/m1"}, admins},
+		{route{fiber.MethodDelete, "/admin/merchants/m1"}, admins},
+		{route{fiber.MethodPost, "/admin/merchants/m1/restore"}, admins},
+		{route{fiber.MethodPost, "/admin/merchants/m1/items"}, admins},
+		{route{fiber.MethodGet, "/admin/merchants/m1/items"}, admins},
+		{route{fiber.MethodPatch, "/admin/merchants/m1/items/i1"}, admins},
+		{route{fiber.MethodDelete, "/admin/merchants/m1/items/i1"}, admins},
+		{route{fiber.MethodGet, "/user/me"}, users},
+		{route{fiber.MethodPatch, "/user/me"}, users},
+		{route{fiber.MethodGet, "/merchants/nearby/1,1"}, users},
+		{route{fiber.MethodGet, "/search?q=nasi"}, users},
+		{route{fiber.MethodPost, "/users/estimate"}, users},
+		{route{fiber.MethodPost, "/users/orders"}, users},
+		{route{fiber.MethodGet, "/users/orders"}, users},
+		{route{fiber.MethodPost, "/auth/roles"}, anyAccount},
+		{route{fiber.MethodPost, "/auth/verify-email/resend"}, anyAccount},
+		{route{fiber.MethodPost, "/auth/logout"}, anyAccount},
+		{route{fiber.MethodPost, "/auth/logout-all"}, anyAccount},
+		{route{fiber.MethodPost, "/auth/mfa/enrol"}, anyAccount},
+		{route{fiber.MethodPost, "/auth/mfa/confirm"}, anyAccount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.route.method+" "+tt.route.path, func(t *testing.T) {
+			if got := requestStatus(t, app, tt.route.method, tt.route.path, ""); got != fiber.StatusUnauthorized {
+				t.Errorf("without token = %d, want 401", got)
+			}
+			if got := requestStatus(t, app, tt.route.method, tt.route.path, "Bearer not.a.token"); got != fiber.StatusUnauthorized {
+				t.Errorf("with invalid token = %d, want 401", got)
+			}
+
+			for _, account := range anyAccount {
+				got := requestStatus(t, app, tt.route.method, tt.route.path, tokens[account])
+				allowed := false
+				for _, role := range tt.allowed {
+					allowed = allowed || role == account
+				}
+				switch {
+				case allowed && (got == fiber.StatusUnauthorized || got == fiber.StatusForbidden):
+					t.Errorf("as %s = %d, want it let through", account, got)
+				case !allowed && got != fiber.StatusForbidden:
+					t.Errorf("as %s = %d, want 403", account, got)
+				}
+			}
+		})
+	}
+}
+
+func TestPublicRoutesDoNotRequireAToken(t *testing.T) {
+	app := newTestApp()
+	routes := []route{
+		{fiber.MethodPost, "/admin/register"},
+		{fiber.MethodPost, "/admin/login"},
+		{fiber.MethodPost, "/user/register"},
+		{fiber.MethodPost, "/user/login"},
+		{fiber.MethodGet, "/.well-known/jwks.json"},
+		{fiber.MethodPost, "/auth/refresh"},
+		{fiber.MethodPost, "/auth/verify-email"},
+		{fiber.MethodPost, "/auth/password/forgot"},
+		{fiber.MethodPost, "/auth/password/reset"},
+		{fiber.MethodPost, "/auth/mfa/verify"},
+		{fiber.MethodPost, "/auth/mfa/pending/enrol"},
+	}
+	for _, r := range routes {
+		t.Run(r.method+" "+r.path, func(t *testing.T) {
+			got := requestStatus(t, app, r.method, r.path, "")
+			if got == fiber.StatusUnauthorized || got == fiber.StatusForbidden {
+				t.Errorf("without token = %d, want it let through", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/config"
+	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/repository"
+	"github.com/nozzlium/belimang/internal/util"
+)
+
+type EstimateService struct {
+	estimateRepository *repository.EstimateRepository
+	merchantRepository *repository.MerchantRepository
+	productRepository  *repository.ProductRepository
+	deliveryConfig     config.DeliveryConfig
+}
+
+func NewEstimateService(
+	estimateRepository *repository.EstimateRepository,
+	merchantRepository *repository.MerchantRepository,
+	productRepository *repository.ProductRepository,
+	deliveryConfig config.DeliveryConfig,
+) *EstimateService {
+	return &EstimateService{
+		estimateRepository: estimateRepository,
+		merchantRepository: merchantRepository,
+		productRepository:  productRepository,
+		deliveryConfig:     deliveryConfig,
+	}
+}
+
+func (s *EstimateService) Estimate(
+	ctx context.Context,
+	estimate model.Estimate,
+) (model.EstimateResponseBody, error) {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return model.EstimateResponseBody{}, err
+	}
+	userID := principal.UserID
+
+	merchants, err := s.merchantRepository.FindByIDs(
+		ctx,
+		estimate.MerchantIDs(),
+	)
+	if err != nil {
+		return model.EstimateResponseBody{}, err
+	}
+	if len(merchants) != len(estimate.Orders) {
+		return model.EstimateResponseBody{}, constant.ErrNotFound
+	}
+
+	for _, merchant := range merchants {
+		distance := util.Haversine(
+			estimate.UserLatitude,
+			estimate.UserLongitude,
+			merchant.Latitude,
+			merchant.Longitude,
+		)
+		if distance > s.deliveryConfig.MaxRadiusKm {
+			return model.EstimateResponseBody{}, constant.ErrTooFar
+		}
+	}
+
+	productIDs := estimate.ProductIDs()
+	products, err := s.productRepository.FindByIDs(
+		ctx,
+		productIDs,
+	)
+	if err != nil {
+		return model.EstimateResponseBody{}, err
+	}
+	if len(products) != len(productIDs) {
+		return model.EstimateResponseBody{}, constant.ErrNotFound
+	}
+	productByID := make(
+		map[uuid.UUID]model.Product,
+		len(products),
+	)
+	for _, product := range products {
+		productByID[product.ID] = product
+	}
+
+	var totalPrice float64
+	for i, order := range estimate.Orders {
+		for j, item := range order.Items {
+			product := productByID[item.ProductID]
+			if product.MerchantID != order.MerchantID {
+				return model.EstimateResponseBody{}, constant.ErrNotFound
+			}
+			if !product.IsAvailable {
+				return model.EstimateResponseBody{}, constant.ErrInsufficientStock
+			}
+			estimate.Orders[i].Items[j].Price = product.Price
+			totalPrice += product.Price * float64(
+				item.Quantity,
+			)
+		}
+	}
+
+	distance := s.routeDistance(
+		estimate,
+		merchants,
+	)
+	estimateID, err := uuid.NewV7()
+	if err != nil {
+		return model.EstimateResponseBody{}, err
+	}
+
+	estimate.ID = estimateID
+	estimate.UserID = userID
+	estimate.TotalPrice = totalPrice
+	estimate.EstimatedDeliveryTimeInMinutes = int(math.Ceil(
+		distance / s.deliveryConfig.CourierSpeedKmh * 60,
+	))
+	estimate.CreatedAt = util.Now()
+
+	savedEstimate, err := s.estimateRepository.Insert(
+		ctx,
+		estimate,
+	)
+	if err != nil {
+		return model.EstimateResponseBody{}, err
+	}
+
+	return savedEstimate.ToResponseBody(), nil
+}
+
+// routeDistance returns the length in kilometers of a nearest-neighbour
+// tour that visits every merchant once and ends at the user. The tour
+// starts at the merchant flagged as the starting point, or otherwise at the
+// merchant farthest from the user so the courier works its way towards them.
+func (s *EstimateService) routeDistance(
+	estimate model.Estimate,
+	merchants []model.Merchant,
+) float64 {
+	var startingPointID uuid.UUID
+	for _, order := range estimate.Orders {
+		if order.IsStartingPoint {
+			startingPointID = order.MerchantID
+		}
+	}
+
+	current := 0
+	farthest := -1.0
+	for i, merchant := range merchants {
+		if merchant.ID == startingPointID {
+			current = i
+			break
+		}
+		distance := util.Haversine(
+			estimate.UserLatitude,
+			estimate.UserLongitude,
+			merchant.Latitude,
+			merchant.Longitude,
+		)
+		if startingPointID == uuid.Nil &&
+			distance > farthest {
+			farthest = distance
+			current = i
+		}
+	}
+
+	visited := make([]bool, len(merchants))
+	visited[current] = true
+	var total float64
+	for range len(merchants) - 1 {
+		next := -1
+		nextDistance := math.MaxFloat64
+		for i, merchant := range merchants {
+			if visited[i] {
+				continue
+			}
+			distance := util.Haversine(
+				merchants[current].Latitude,
+				merchants[current].Longitude,
+				merchant.Latitude,
+				merchant.Longitude,
+			)
+			if distance < nextDistance {
+				next = i
+				nextDistance = distance
+			}
+		}
+		visited[next] = true
+		total += nextDistance
+		current = next
+	}
+
+	return total + util.Haversine(
+		merchants[current].Latitude,
+		merchants[current].Longitude,
+		estimate.UserLatitude,
+		estimate.UserLongitude,
+	)
+}
@@ -11,13 +11,16 @@ import (
 
 type MerchantService struct {
 	merchantRepository *repository.MerchantRepository
+	productRepository  *repository.ProductRepository
 }
 
 func NewMerchantService(
 	merchantRepository *repository.MerchantRepository,
+	productRepository *repository.ProductRepository,
 ) *MerchantService {
 	return &MerchantService{
 		merchantRepository: merchantRepository,
+		productRepository:  productRepository,
 	}
 }
 
@@ -25,13 +28,11 @@ func (s *MerchantService) Create(
 	ctx context.Context,
 	merchant model.Merchant,
 ) (uuid.UUID, error) {
-	userIDString := ctx.Value("userID").(string)
-	userID, err := uuid.Parse(
-		userIDString,
-	)
+	principal, err := model.PrincipalFromContext(ctx)
 	if err != nil {
 		return uuid.UUID{}, err
 	}
+	userID := principal.UserID
 
 	merchantId, err := uuid.NewV7()
 	if err != nil {
@@ -58,19 +59,163 @@ func (s *MerchantService) FindAll(
 	ctx context.Context,
 	merchantQueries model.MerchantQueries,
 ) ([]model.MerchantResponaeBody, int, error) {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	userID := principal.UserID
+	merchantQueries.UserID = userID
+
+	merchants, total, err := s.merchantRepository.FindAll(
+		ctx,
+		merchantQueries,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	merchantData := make(
 		[]model.MerchantResponaeBody,
 		0,
-		merchantQueries.Limit,
+		len(merchants),
 	)
-	merchants, total, err := s.merchantRepository.FindAll(
+	for _, merchant := range merchants {
+		merchantData = append(
+			merchantData,
+			merchant.ToResponseBody(),
+		)
+	}
+
+	return merchantData, total, nil
+}
+
+func (s *MerchantService) FindPage(
+	ctx context.Context,
+	merchantQueries model.MerchantQueries,
+) ([]model.MerchantResponaeBody, model.CursorMeta, error) {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return nil, model.CursorMeta{}, err
+	}
+	merchantQueries.UserID = principal.UserID
+
+	page, err := s.merchantRepository.FindPage(
 		ctx,
 		merchantQueries,
 	)
+	if err != nil {
+		return nil, model.CursorMeta{}, err
+	}
+
+	merchantData := make(
+		[]model.MerchantResponaeBody,
+		0,
+		len(page.Items),
+	)
+	for _, merchant := range page.Items {
+		merchantData = append(
+			merchantData,
+			merchant.ToResponseBody(),
+		)
+	}
+
+	limit, _ := merchantQueries.Pagination()
+	return merchantData, model.NewCursorMeta(
+		page,
+		limit,
+		merchantQueries.Total,
+	), nil
+}
+
+func (s *MerchantService) FindNearby(
+	ctx context.Context,
+	nearbyQueries model.MerchantNearbyQueries,
+) ([]model.MerchantNearbyResponseBody, int, error) {
+	merchants, total, err := s.merchantRepository.FindNearby(
+		ctx,
+		nearbyQueries,
+	)
 	if err != nil {
 		return nil, 0, err
 	}
 
+	merchantIDs := make(
+		[]uuid.UUID,
+		0,
+		len(merchants),
+	)
+	for _, merchant := range merchants {
+		merchantIDs = append(
+			merchantIDs,
+			merchant.ID,
+		)
+	}
+
+	products, err := s.productRepository.FindByMerchantIDs(
+		ctx,
+		merchantIDs,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	itemsByMerchant := make(
+		map[uuid.UUID][]model.ProductData,
+		len(merchants),
+	)
+	for _, product := range products {
+		itemsByMerchant[product.MerchantID] = append(
+			itemsByMerchant[product.MerchantID],
+			product.ToProductData(),
+		)
+	}
+
+	nearbyData := make(
+		[]model.MerchantNearbyResponseBody,
+		0,
+		len(merchants),
+	)
+	for _, merchant := range merchants {
+		items := itemsByMerchant[merchant.ID]
+		if items == nil {
+			items = []model.ProductData{}
+		}
+		nearbyData = append(
+			nearbyData,
+			model.MerchantNearbyResponseBody{
+				Merchant: merchant.ToResponseBody(),
+				Items:    items,
+			},
+		)
+	}
+
+	return nearbyData, total, nil
+}
+
+func (s *MerchantService) FindTrashed(
+	ctx context.Context,
+	merchantQueries model.MerchantQueries,
+) ([]model.MerchantResponaeBody, int, error) {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	userID := principal.UserID
+	merchantQueries.UserID = userID
+
+	merchants, total, err := s.merchantRepository.FindTrashed(
+		ctx,
+		merchantQueries,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	merchantData := make(
+		[]model.MerchantResponaeBody,
+		0,
+		len(merchants),
+	)
 	for _, merchant := range merchants {
 		merchantData = append(
 			merchantData,
@@ -80,3 +225,81 @@ func (s *MerchantService) FindAll(
 
 	return merchantData, total, nil
 }
+
+func (s *MerchantService) Update(
+	ctx context.Context,
+	merchantID uuid.UUID,
+	patch model.MerchantPatchRequestBody,
+) (model.MerchantResponaeBody, error) {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return model.MerchantResponaeBody{}, err
+	}
+	userID := principal.UserID
+
+	merchant, err := s.merchantRepository.FindByIDAndUserID(
+		ctx,
+		model.Merchant{
+			ID:     merchantID,
+			UserID: userID,
+		},
+	)
+	if err != nil {
+		return model.MerchantResponaeBody{}, err
+	}
+
+	merchant, err = patch.Apply(merchant)
+	if err != nil {
+		return model.MerchantResponaeBody{}, err
+	}
+
+	merchant, err = s.merchantRepository.Update(
+		ctx,
+		merchant,
+	)
+	if err != nil {
+		return model.MerchantResponaeBody{}, err
+	}
+
+	return merchant.ToResponseBody(), nil
+}
+
+func (s *MerchantService) Delete(
+	ctx context.Context,
+	merchantID uuid.UUID,
+) error {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	userID := principal.UserID
+
+	deletedAt := util.Now()
+	return s.merchantRepository.SoftDelete(
+		ctx,
+		model.Merchant{
+			ID:        merchantID,
+			UserID:    userID,
+			DeletedAt: &deletedAt,
+		},
+	)
+}
+
+func (s *MerchantService) Restore(
+	ctx context.Context,
+	merchantID uuid.UUID,
+) error {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	userID := principal.UserID
+
+	return s.merchantRepository.Restore(
+		ctx,
+		model.Merchant{
+			ID:     merchantID,
+			UserID: userID,
+		},
+	)
+}
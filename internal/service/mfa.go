@@ -0,0 +1,481 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/totp"
+	"github.com/nozzlium/belimang/internal/util"
+)
+
+// EnrolMFA starts two-factor enrolment for the caller. It stays inactive
+// until ConfirmMFA receives a code from the authenticator.
+func (s *UserService) EnrolMFA(
+	ctx context.Context,
+) (model.MFAEnrolment, error) {
+	user, err := s.findMe(ctx)
+	if err != nil {
+		return model.MFAEnrolment{}, err
+	}
+
+	return s.enrolMFA(
+		ctx,
+		user,
+	)
+}
+
+// EnrolPendingMFA starts enrolment for an admin whose login is held back
+// because two-factor authentication is required but not set up yet. The
+// enrolment is completed by VerifyMFA with the same mfa token.
+func (s *UserService) EnrolPendingMFA(
+	ctx context.Context,
+	mfaToken string,
+) (model.MFAEnrolment, error) {
+	user, err := s.findPendingMFAUser(
+		ctx,
+		mfaToken,
+	)
+	if err != nil {
+		return model.MFAEnrolment{}, err
+	}
+
+	return s.enrolMFA(
+		ctx,
+		user,
+	)
+}
+
+// ConfirmMFA enables the caller's enrolment once the code checks out and
+// returns the recovery codes, which are not shown again.
+func (s *UserService) ConfirmMFA(
+	ctx context.Context,
+	code string,
+) ([]string, error) {
+	user, err := s.findMe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mfa, err := s.mfaRepository.FindByUserID(
+		ctx,
+		model.MFA{
+			UserID: user.ID,
+		},
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			constant.ErrNotFound,
+		) {
+			return nil, constant.ErrBadInput
+		}
+		return nil, err
+	}
+	if mfa.ConfirmedAt != nil {
+		return nil, constant.ErrConflict
+	}
+
+	now := time.Now()
+	ok, err := s.checkSecondFactor(
+		ctx,
+		mfa,
+		code,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, constant.ErrBadInput
+	}
+
+	return s.confirmMFA(
+		ctx,
+		mfa,
+		now,
+	)
+}
+
+// VerifyMFA exchanges the mfa token of a login for the access and refresh
+// tokens, given a totp code or an unused recovery code. A wrong code counts
+// as a failed login. The mfa token survives wrong codes, so a typo does
+// not mean logging in again.
+func (s *UserService) VerifyMFA(
+	ctx context.Context,
+	mfaToken string,
+	code string,
+	ipAddress string,
+) (model.AuthTokens, error) {
+	user, err := s.findPendingMFAUser(
+		ctx,
+		mfaToken,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	attempt, err := s.startLoginAttempt(
+		ctx,
+		strings.ToLower(user.Username),
+		ipAddress,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	mfa, err := s.mfaRepository.FindByUserID(
+		ctx,
+		model.MFA{
+			UserID: user.ID,
+		},
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			constant.ErrNotFound,
+		) {
+			return model.AuthTokens{}, constant.ErrBadInput
+		}
+		return model.AuthTokens{}, err
+	}
+
+	ok, err := s.checkSecondFactor(
+		ctx,
+		mfa,
+		code,
+		attempt.CreatedAt,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	if !ok {
+		return model.AuthTokens{}, s.recordLoginFailure(
+			ctx,
+			attempt,
+		)
+	}
+
+	_, err = s.consumeActionToken(
+		ctx,
+		mfaToken,
+		model.TokenPurposeMFALogin,
+		attempt.CreatedAt,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	var recoveryCodes []string
+	if mfa.ConfirmedAt == nil {
+		recoveryCodes, err = s.confirmMFA(
+			ctx,
+			mfa,
+			attempt.CreatedAt,
+		)
+		if err != nil {
+			return model.AuthTokens{}, err
+		}
+	}
+
+	attempt.Succeeded = true
+	err = s.loginAttemptRepository.RecordSuccess(
+		ctx,
+		attempt,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	tokens, err := s.issueTokens(
+		ctx,
+		user,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	tokens.RecoveryCodes = recoveryCodes
+
+	return tokens, nil
+}
+
+func (s *UserService) FindMFASettings(
+	ctx context.Context,
+) (model.MFASettings, error) {
+	return s.mfaRepository.FindSettings(ctx)
+}
+
+// UpdateMFASettings applies to the next login of every admin. Admins
+// without two-factor authentication have to enrol before they get tokens.
+func (s *UserService) UpdateMFASettings(
+	ctx context.Context,
+	settings model.MFASettings,
+) (model.MFASettings, error) {
+	err := s.mfaRepository.UpdateSettings(
+		ctx,
+		settings,
+		time.Now(),
+	)
+	if err != nil {
+		return model.MFASettings{}, err
+	}
+
+	return settings, nil
+}
+
+// mfaRequirement reports whether the user needs a second factor to log in,
+// and whether they have to enrol one first because it is required for
+// admins.
+func (s *UserService) mfaRequirement(
+	ctx context.Context,
+	user model.User,
+) (bool, bool, error) {
+	mfa, err := s.mfaRepository.FindByUserID(
+		ctx,
+		model.MFA{
+			UserID: user.ID,
+		},
+	)
+	if err != nil && !errors.Is(
+		err,
+		constant.ErrNotFound,
+	) {
+		return false, false, err
+	}
+	if err == nil && mfa.ConfirmedAt != nil {
+		return true, false, nil
+	}
+
+	if !user.HasRole(model.RoleAdmin) {
+		return false, false, nil
+	}
+	settings, err := s.mfaRepository.FindSettings(ctx)
+	if err != nil {
+		return false, false, err
+	}
+
+	return settings.RequiredForAdmins, settings.RequiredForAdmins, nil
+}
+
+func (s *UserService) issueMFAToken(
+	ctx context.Context,
+	user model.User,
+	enrolmentRequired bool,
+) (model.AuthTokens, error) {
+	rawToken, _, err := s.issueActionToken(
+		ctx,
+		user,
+		model.TokenPurposeMFALogin,
+		s.mfaConfig.PendingTokenTTL,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	return model.AuthTokens{
+		MFAToken:             rawToken,
+		MFAEnrolmentRequired: enrolmentRequired,
+	}, nil
+}
+
+// findPendingMFAUser fails with constant.ErrUnauthorized when the mfa
+// token is unknown, used or expired.
+func (s *UserService) findPendingMFAUser(
+	ctx context.Context,
+	mfaToken string,
+) (model.User, error) {
+	actionToken, err := s.tokenRepository.FindActionToken(
+		ctx,
+		model.ActionToken{
+			TokenHash: hashToken(mfaToken),
+			Purpose:   model.TokenPurposeMFALogin,
+		},
+		time.Now(),
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			constant.ErrNotFound,
+		) {
+			return model.User{}, constant.ErrUnauthorized
+		}
+		return model.User{}, err
+	}
+
+	user, err := s.userRepository.FindByID(
+		ctx,
+		model.User{
+			ID: actionToken.UserID,
+		},
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			constant.ErrNotFound,
+		) {
+			return model.User{}, constant.ErrUnauthorized
+		}
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+func (s *UserService) enrolMFA(
+	ctx context.Context,
+	user model.User,
+) (model.MFAEnrolment, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return model.MFAEnrolment{}, err
+	}
+	encryptedSecret, err := util.Encrypt(
+		s.mfaConfig.EncryptionKey,
+		[]byte(secret),
+	)
+	if err != nil {
+		return model.MFAEnrolment{}, err
+	}
+
+	err = s.mfaRepository.Enrol(
+		ctx,
+		model.MFA{
+			UserID:    user.ID,
+			Secret:    encryptedSecret,
+			CreatedAt: time.Now(),
+		},
+	)
+	if err != nil {
+		return model.MFAEnrolment{}, err
+	}
+
+	return model.MFAEnrolment{
+		Secret: secret,
+		ProvisioningURI: totp.ProvisioningURI(
+			s.mfaConfig.Issuer,
+			user.Username,
+			secret,
+		),
+	}, nil
+}
+
+// checkSecondFactor accepts a totp code of a step that was not used yet,
+// or, once the enrolment is confirmed, an unused recovery code.
+func (s *UserService) checkSecondFactor(
+	ctx context.Context,
+	mfa model.MFA,
+	code string,
+	now time.Time,
+) (bool, error) {
+	if len(code) == totp.Digits {
+		secret, err := util.Decrypt(
+			s.mfaConfig.EncryptionKey,
+			mfa.Secret,
+		)
+		if err != nil {
+			return false, err
+		}
+		step, ok := totp.Validate(
+			string(secret),
+			code,
+			now,
+		)
+		if !ok {
+			return false, nil
+		}
+		return s.mfaRepository.UseStep(
+			ctx,
+			mfa,
+			step,
+		)
+	}
+
+	if mfa.ConfirmedAt == nil {
+		return false, nil
+	}
+
+	return s.mfaRepository.UseRecoveryCode(
+		ctx,
+		model.RecoveryCode{
+			UserID:   mfa.UserID,
+			CodeHash: hashToken(model.NormalizeRecoveryCode(code)),
+		},
+		now,
+	)
+}
+
+// confirmMFA enables the enrolment with a fresh set of recovery codes and
+// returns the raw codes.
+func (s *UserService) confirmMFA(
+	ctx context.Context,
+	mfa model.MFA,
+	now time.Time,
+) ([]string, error) {
+	rawCodes := make([]string, 0, s.mfaConfig.RecoveryCodeCount)
+	recoveryCodes := make([]model.RecoveryCode, 0, s.mfaConfig.RecoveryCodeCount)
+	for range s.mfaConfig.RecoveryCodeCount {
+		codeID, err := uuid.NewV7()
+		if err != nil {
+			return nil, err
+		}
+		rawCode, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		rawCodes = append(
+			rawCodes,
+			rawCode,
+		)
+		recoveryCodes = append(
+			recoveryCodes,
+			model.RecoveryCode{
+				ID:        codeID,
+				UserID:    mfa.UserID,
+				CodeHash:  hashToken(model.NormalizeRecoveryCode(rawCode)),
+				CreatedAt: now,
+			},
+		)
+	}
+
+	mfa.ConfirmedAt = &now
+	err := s.mfaRepository.Confirm(
+		ctx,
+		mfa,
+		recoveryCodes,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return rawCodes, nil
+}
+
+// generateRecoveryCode returns 80 random bits as four dash separated groups
+// of four lowercase base32 characters.
+func generateRecoveryCode() (string, error) {
+	raw := make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	encoded := strings.ToLower(
+		base32.StdEncoding.EncodeToString(raw),
+	)
+
+	return strings.Join(
+		[]string{
+			encoded[0:4],
+			encoded[4:8],
+			encoded[8:12],
+			encoded[12:16],
+		},
+		"-",
+	), nil
+}
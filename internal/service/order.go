@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/repository"
+	"github.com/nozzlium/belimang/internal/util"
+)
+
+type OrderService struct {
+	orderRepository    *repository.OrderRepository
+	estimateRepository *repository.EstimateRepository
+	userRepository     *repository.UserRepository
+}
+
+func NewOrderService(
+	orderRepository *repository.OrderRepository,
+	estimateRepository *repository.EstimateRepository,
+	userRepository *repository.UserRepository,
+) *OrderService {
+	return &OrderService{
+		orderRepository:    orderRepository,
+		estimateRepository: estimateRepository,
+		userRepository:     userRepository,
+	}
+}
+
+func (s *OrderService) Create(
+	ctx context.Context,
+	order model.Order,
+) (uuid.UUID, error) {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	userID := principal.UserID
+
+	user, err := s.userRepository.FindByID(
+		ctx,
+		model.User{
+			ID: userID,
+		},
+	)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	if user.EmailVerifiedAt == nil {
+		return uuid.UUID{}, constant.ErrEmailNotVerified
+	}
+
+	estimate, err := s.estimateRepository.FindByID(
+		ctx,
+		model.Estimate{ID: order.EstimateID},
+	)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	if estimate.UserID != userID {
+		return uuid.UUID{}, constant.ErrNotFound
+	}
+
+	orderId, err := uuid.NewV7()
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	order.ID = orderId
+	order.UserID = userID
+	order.CreatedAt = util.Now()
+
+	_, err = s.orderRepository.Insert(
+		ctx,
+		order,
+	)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return orderId, nil
+}
+
+func (s *OrderService) FindAll(
+	ctx context.Context,
+	queries model.OrderQueries,
+) ([]model.OrderResponseBody, int, error) {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	userID := principal.UserID
+	queries.UserID = userID
+
+	items, total, err := s.orderRepository.FindAll(
+		ctx,
+		queries,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// items arrive sorted by order and then merchant, so a new group
+	// starts whenever either id changes from the previous row.
+	orderData := make(
+		[]model.OrderResponseBody,
+		0,
+	)
+	for i, item := range items {
+		if i == 0 || items[i-1].OrderID != item.OrderID {
+			orderData = append(
+				orderData,
+				model.OrderResponseBody{
+					OrderID: item.OrderID.String(),
+					Orders:  []model.OrderMerchantResponseBody{},
+				},
+			)
+		}
+		order := &orderData[len(orderData)-1]
+
+		if i == 0 ||
+			items[i-1].OrderID != item.OrderID ||
+			items[i-1].Merchant.ID != item.Merchant.ID {
+			order.Orders = append(
+				order.Orders,
+				model.OrderMerchantResponseBody{
+					Merchant: item.Merchant.ToResponseBody(),
+					Items:    []model.OrderItemResponseBody{},
+				},
+			)
+		}
+		merchantOrder := &order.Orders[len(order.Orders)-1]
+		merchantOrder.Items = append(
+			merchantOrder.Items,
+			item.ToItemResponseBody(),
+		)
+	}
+
+	return orderData, total, nil
+}
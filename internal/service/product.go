@@ -10,14 +10,17 @@ import (
 )
 
 type ProductService struct {
-	productRepository *repository.ProductRepository
+	productRepository  *repository.ProductRepository
+	merchantRepository *repository.MerchantRepository
 }
 
 func NewProductService(
 	productRepository *repository.ProductRepository,
+	merchantRepository *repository.MerchantRepository,
 ) *ProductService {
 	return &ProductService{
-		productRepository: productRepository,
+		productRepository:  productRepository,
+		merchantRepository: merchantRepository,
 	}
 }
 
@@ -25,9 +28,9 @@ func (s *ProductService) Create(
 	ctx context.Context,
 	product model.Product,
 ) (uuid.UUID, error) {
-	userIDString := ctx.Value("userID").(string)
-	userID, err := uuid.Parse(
-		userIDString,
+	merchant, err := s.findOwnedMerchant(
+		ctx,
+		product.MerchantID,
 	)
 	if err != nil {
 		return uuid.UUID{}, err
@@ -40,7 +43,7 @@ func (s *ProductService) Create(
 
 	currentDate := util.Now()
 	product.ID = productId
-	product.UserID = userID
+	product.UserID = merchant.UserID
 	product.CreatedAt = currentDate
 
 	err = s.productRepository.Insert(
@@ -58,6 +61,14 @@ func (s *ProductService) FindAll(
 	ctx context.Context,
 	queries model.ProductQueries,
 ) (model.ProductItemsResponseBody, error) {
+	_, err := s.findOwnedMerchant(
+		ctx,
+		queries.MerchantId,
+	)
+	if err != nil {
+		return model.ProductItemsResponseBody{}, err
+	}
+
 	products, total, err := s.productRepository.FindAll(
 		ctx,
 		queries,
@@ -89,3 +100,133 @@ func (s *ProductService) FindAll(
 
 	return productResponse, nil
 }
+
+func (s *ProductService) FindPage(
+	ctx context.Context,
+	queries model.ProductQueries,
+) (model.ProductPageResponseBody, error) {
+	_, err := s.findOwnedMerchant(
+		ctx,
+		queries.MerchantId,
+	)
+	if err != nil {
+		return model.ProductPageResponseBody{}, err
+	}
+
+	page, err := s.productRepository.FindPage(
+		ctx,
+		queries,
+	)
+	if err != nil {
+		return model.ProductPageResponseBody{}, err
+	}
+
+	productData := make(
+		[]model.ProductData,
+		0,
+		len(page.Items),
+	)
+	for _, product := range page.Items {
+		productData = append(
+			productData,
+			product.ToProductData(),
+		)
+	}
+
+	limit, _ := queries.Pagination()
+	return model.ProductPageResponseBody{
+		Data: productData,
+		Meta: model.NewCursorMeta(
+			page,
+			limit,
+			queries.Total,
+		),
+	}, nil
+}
+
+func (s *ProductService) Update(
+	ctx context.Context,
+	merchantID uuid.UUID,
+	productID uuid.UUID,
+	patch model.ProductPatchRequestBody,
+) (model.ProductData, error) {
+	_, err := s.findOwnedMerchant(
+		ctx,
+		merchantID,
+	)
+	if err != nil {
+		return model.ProductData{}, err
+	}
+
+	product, err := s.productRepository.FindByIDAndMerchantID(
+		ctx,
+		model.Product{
+			ID:         productID,
+			MerchantID: merchantID,
+		},
+	)
+	if err != nil {
+		return model.ProductData{}, err
+	}
+
+	product, err = patch.Apply(product)
+	if err != nil {
+		return model.ProductData{}, err
+	}
+
+	product, err = s.productRepository.Update(
+		ctx,
+		product,
+	)
+	if err != nil {
+		return model.ProductData{}, err
+	}
+
+	return product.ToProductData(), nil
+}
+
+func (s *ProductService) Delete(
+	ctx context.Context,
+	merchantID uuid.UUID,
+	productID uuid.UUID,
+) error {
+	_, err := s.findOwnedMerchant(
+		ctx,
+		merchantID,
+	)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := util.Now()
+	return s.productRepository.SoftDelete(
+		ctx,
+		model.Product{
+			ID:         productID,
+			MerchantID: merchantID,
+			DeletedAt:  &deletedAt,
+		},
+	)
+}
+
+// findOwnedMerchant returns the merchant only when it belongs to the admin
+// making the request. Merchants owned by other admins are reported as
+// constant.ErrNotFound so their existence is not leaked.
+func (s *ProductService) findOwnedMerchant(
+	ctx context.Context,
+	merchantID uuid.UUID,
+) (model.Merchant, error) {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return model.Merchant{}, err
+	}
+	userID := principal.UserID
+
+	return s.merchantRepository.FindByIDAndUserID(
+		ctx,
+		model.Merchant{
+			ID:     merchantID,
+			UserID: userID,
+		},
+	)
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+
+	"github.com/nozzlium/belimang/internal/config"
+	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/repository"
+)
+
+type SearchService struct {
+	searchRepository *repository.SearchRepository
+	searchConfig     config.SearchConfig
+}
+
+func NewSearchService(
+	searchRepository *repository.SearchRepository,
+	searchConfig config.SearchConfig,
+) *SearchService {
+	return &SearchService{
+		searchRepository: searchRepository,
+		searchConfig:     searchConfig,
+	}
+}
+
+func (s *SearchService) Search(
+	ctx context.Context,
+	queries model.SearchQueries,
+) ([]model.SearchHitResponseBody, int, error) {
+	hits, total, err := s.searchRepository.Search(
+		ctx,
+		queries,
+		s.searchConfig.MinSimilarity,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hitData := make(
+		[]model.SearchHitResponseBody,
+		0,
+		len(hits),
+	)
+	for _, hit := range hits {
+		hitData = append(
+			hitData,
+			hit.ToResponseBody(
+				queries.Query,
+				s.searchConfig.MinSimilarity,
+			),
+		)
+	}
+
+	return hitData, total, nil
+}
@@ -2,214 +2,1138 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/config"
 	"github.com/nozzlium/belimang/internal/constant"
+	"github.com/nozzlium/belimang/internal/mailer"
 	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/password"
 	"github.com/nozzlium/belimang/internal/repository"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService struct {
-	userRepository *repository.UserRepository
-	secret         string
-	salt           int
+	userRepository         *repository.UserRepository
+	tokenRepository        *repository.TokenRepository
+	loginAttemptRepository *repository.LoginAttemptRepository
+	mfaRepository          *repository.MFARepository
+	passwordHasher         password.Hasher
+	mailer                 mailer.Mailer
+	authConfig             config.AuthConfig
+	loginConfig            config.LoginConfig
+	mailConfig             config.MailConfig
+	mfaConfig              config.MFAConfig
 }
 
 func NewUserService(
 	userRepository *repository.UserRepository,
-	secret string,
-	salt int,
+	tokenRepository *repository.TokenRepository,
+	loginAttemptRepository *repository.LoginAttemptRepository,
+	mfaRepository *repository.MFARepository,
+	passwordHasher password.Hasher,
+	mailer mailer.Mailer,
+	authConfig config.AuthConfig,
+	loginConfig config.LoginConfig,
+	mailConfig config.MailConfig,
+	mfaConfig config.MFAConfig,
 ) *UserService {
 	return &UserService{
-		userRepository: userRepository,
+		userRepository:         userRepository,
+		tokenRepository:        tokenRepository,
+		loginAttemptRepository: loginAttemptRepository,
+		mfaRepository:          mfaRepository,
+		passwordHasher:         passwordHasher,
+		mailer:                 mailer,
+		authConfig:             authConfig,
+		loginConfig:            loginConfig,
+		mailConfig:             mailConfig,
+		mfaConfig:              mfaConfig,
 	}
 }
 
 func (s *UserService) RegisterAdmin(
 	ctx context.Context,
 	user model.User,
-) (string, error) {
+) (model.AuthTokens, error) {
+	return s.register(
+		ctx,
+		user,
+		model.RoleAdmin,
+	)
+}
+
+func (s *UserService) LoginAdmin(
+	ctx context.Context,
+	user model.User,
+	ipAddress string,
+) (model.AuthTokens, error) {
+	return s.login(
+		ctx,
+		user,
+		ipAddress,
+		model.RoleAdmin,
+	)
+}
+
+func (s *UserService) RegisterUser(
+	ctx context.Context,
+	user model.User,
+) (model.AuthTokens, error) {
+	return s.register(
+		ctx,
+		user,
+		model.RoleUser,
+	)
+}
+
+func (s *UserService) LoginUser(
+	ctx context.Context,
+	user model.User,
+	ipAddress string,
+) (model.AuthTokens, error) {
+	return s.login(
+		ctx,
+		user,
+		ipAddress,
+		model.RoleUser,
+	)
+}
+
+// AddRole grants the caller another role on their account. The role shows
+// up in access tokens from the next refresh on. While two-factor
+// authentication is required for admins, only accounts that have enrolled
+// can take the admin role.
+func (s *UserService) AddRole(
+	ctx context.Context,
+	role model.Role,
+) (model.UserResponseBody, error) {
+	user, err := s.findMe(ctx)
+	if err != nil {
+		return model.UserResponseBody{}, err
+	}
+	if user.HasRole(role) {
+		return user.ToResponseBody(), nil
+	}
+
+	if role == model.RoleAdmin {
+		admin := user
+		admin.Roles = append(
+			slices.Clone(user.Roles),
+			model.RoleAdmin,
+		)
+		_, enrolmentRequired, err := s.mfaRequirement(
+			ctx,
+			admin,
+		)
+		if err != nil {
+			return model.UserResponseBody{}, err
+		}
+		if enrolmentRequired {
+			return model.UserResponseBody{}, fmt.Errorf(
+				"%w: enrol in two-factor authentication before becoming an admin",
+				constant.ErrForbidden,
+			)
+		}
+	}
+
+	err = s.userRepository.AddRole(
+		ctx,
+		user,
+		role,
+		time.Now(),
+	)
+	if err != nil {
+		return model.UserResponseBody{}, err
+	}
+	user.Roles = append(
+		user.Roles,
+		role,
+	)
+
+	return user.ToResponseBody(), nil
+}
+
+func (s *UserService) register(
+	ctx context.Context,
+	user model.User,
+	role model.Role,
+) (model.AuthTokens, error) {
 	userId, err := uuid.NewV7()
 	if err != nil {
-		return "", err
+		return model.AuthTokens{}, err
 	}
 
-	generatedHashBytes, err := bcrypt.GenerateFromPassword(
-		[]byte(user.Password),
-		s.salt,
+	hash, err := s.passwordHasher.Hash(
+		user.Password,
 	)
 	if err != nil {
-		return "", err
+		return model.AuthTokens{}, err
 	}
 
 	user.ID = userId
-	user.Password = string(
-		generatedHashBytes,
-	)
-	savedUser, err := s.userRepository.CreateAdmin(
+	user.Password = hash
+	user.Roles = []model.Role{role}
+	savedUser, err := s.userRepository.Create(
 		ctx,
 		user,
 	)
 	if err != nil {
-		return "", err
+		return model.AuthTokens{}, err
 	}
 
-	token, err := generateJwtToken(
-		s.secret,
+	// the account is usable without a verified email, so a mail that
+	// failed to go out can be requested again later.
+	err = s.sendVerificationEmail(
+		ctx,
 		savedUser,
 	)
 	if err != nil {
-		return "", err
+		log.Printf("failed to send verification email to %s: %v", savedUser.ID, err)
+	}
+
+	// new admins enrol a second factor first when it is required for them
+	mfaRequired, enrolmentRequired, err := s.mfaRequirement(
+		ctx,
+		savedUser,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	if mfaRequired {
+		return s.issueMFAToken(
+			ctx,
+			savedUser,
+			enrolmentRequired,
+		)
 	}
 
-	return token, nil
+	return s.issueTokens(
+		ctx,
+		savedUser,
+	)
 }
 
-func (s *UserService) LoginAdmin(
+func (s *UserService) Refresh(
 	ctx context.Context,
-	user model.User,
-) (string, error) {
-	savedUser, err := s.userRepository.FindAdminByUsername(
+	refreshToken string,
+) (model.AuthTokens, error) {
+	savedToken, err := s.tokenRepository.FindRefreshTokenByHash(
 		ctx,
-		user,
+		model.RefreshToken{
+			TokenHash: hashToken(refreshToken),
+		},
 	)
 	if err != nil {
 		if errors.Is(
 			err,
 			constant.ErrNotFound,
 		) {
-			return "", constant.ErrBadInput
+			return model.AuthTokens{}, constant.ErrUnauthorized
 		}
-		return "", err
+		return model.AuthTokens{}, err
+	}
+
+	now := time.Now()
+	if savedToken.RevokedAt != nil {
+		// a revoked refresh token being presented again means it leaked,
+		// so the whole session it belongs to is killed.
+		err := s.tokenRepository.RevokeFamily(
+			ctx,
+			savedToken.FamilyID,
+			now,
+		)
+		if err != nil {
+			return model.AuthTokens{}, err
+		}
+		return model.AuthTokens{}, constant.ErrUnauthorized
+	}
+	if now.After(savedToken.ExpiresAt) {
+		return model.AuthTokens{}, constant.ErrUnauthorized
 	}
 
-	if err := bcrypt.CompareHashAndPassword(
-		[]byte(savedUser.Password),
-		[]byte(user.Password),
-	); err != nil {
-		return "", constant.ErrBadInput
+	user, err := s.userRepository.FindByID(
+		ctx,
+		model.User{
+			ID: savedToken.UserID,
+		},
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			constant.ErrNotFound,
+		) {
+			return model.AuthTokens{}, constant.ErrUnauthorized
+		}
+		return model.AuthTokens{}, err
+	}
+
+	// admins who have not enrolled while it is required for them, such as
+	// sessions from before it was turned on, have to log in again to enrol.
+	_, enrolmentRequired, err := s.mfaRequirement(
+		ctx,
+		user,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	if enrolmentRequired {
+		return model.AuthTokens{}, fmt.Errorf(
+			"%w: two-factor authentication is required for admins",
+			constant.ErrUnauthorized,
+		)
+	}
+
+	newRefreshToken, rawRefreshToken, err := s.newRefreshToken(
+		user,
+		savedToken.FamilyID,
+		now,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	err = s.tokenRepository.RotateRefreshToken(
+		ctx,
+		savedToken,
+		newRefreshToken,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
 	}
 
-	token, err := generateJwtToken(
-		s.secret,
+	accessToken, err := generateJwtToken(
+		s.authConfig,
+		user,
+		savedToken.FamilyID,
+		now,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	return model.AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: rawRefreshToken,
+	}, nil
+}
+
+// Logout revokes the access token used for the request and every refresh
+// token of the session it was issued for.
+func (s *UserService) Logout(
+	ctx context.Context,
+) error {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = s.tokenRepository.RevokeAccessToken(
+		ctx,
+		principal.TokenID,
+		principal.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.tokenRepository.RevokeFamily(
+		ctx,
+		principal.SessionID,
+		now,
+	)
+}
+
+// LogoutAll revokes every refresh token of the caller and every access
+// token issued to them so far.
+func (s *UserService) LogoutAll(
+	ctx context.Context,
+) error {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.tokenRepository.RevokeAllForUser(
+		ctx,
+		principal.UserID,
+		time.Now(),
+	)
+}
+
+func (s *UserService) IsRevoked(
+	ctx context.Context,
+	tokenID uuid.UUID,
+	userID uuid.UUID,
+	issuedAt time.Time,
+) (bool, error) {
+	return s.tokenRepository.IsAccessTokenRevoked(
+		ctx,
+		tokenID,
+		userID,
+		issuedAt,
+	)
+}
+
+// login authenticates an account holding role by its username or email.
+// The tokens it returns carry every role of the account. Failed attempts
+// count towards a lockout of both the account and the client ip, and
+// unknown accounts count the same as wrong passwords. Accounts that need a
+// second factor only get an mfa token, see VerifyMFA.
+func (s *UserService) login(
+	ctx context.Context,
+	user model.User,
+	ipAddress string,
+	role model.Role,
+) (model.AuthTokens, error) {
+	var savedUser model.User
+	var err error
+	if user.Email != "" {
+		savedUser, err = s.userRepository.FindByEmail(
+			ctx,
+			user,
+		)
+	} else {
+		savedUser, err = s.userRepository.FindByUsername(
+			ctx,
+			user,
+		)
+	}
+	found := err == nil
+	if err != nil && !errors.Is(
+		err,
+		constant.ErrNotFound,
+	) {
+		return model.AuthTokens{}, err
+	}
+
+	// failures are counted per account whichever identifier was used, and
+	// per identifier for logins that match no account.
+	lockoutKey := savedUser.Username
+	if !found {
+		lockoutKey = user.Username + user.Email
+	}
+	lockoutKey = strings.ToLower(lockoutKey)
+
+	attempt, err := s.startLoginAttempt(
+		ctx,
+		lockoutKey,
+		ipAddress,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	err = constant.ErrBadInput
+	if found {
+		err = s.checkPassword(
+			ctx,
+			savedUser,
+			user.Password,
+		)
+	}
+	if err != nil {
+		if errors.Is(
+			err,
+			constant.ErrBadInput,
+		) {
+			return model.AuthTokens{}, s.recordLoginFailure(
+				ctx,
+				attempt,
+			)
+		}
+		return model.AuthTokens{}, err
+	}
+	if !savedUser.HasRole(role) {
+		return model.AuthTokens{}, constant.ErrBadInput
+	}
+
+	attempt.Succeeded = true
+	err = s.loginAttemptRepository.RecordSuccess(
+		ctx,
+		attempt,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	mfaRequired, enrolmentRequired, err := s.mfaRequirement(
+		ctx,
 		savedUser,
 	)
 	if err != nil {
-		return "", err
+		return model.AuthTokens{}, err
+	}
+	if mfaRequired {
+		return s.issueMFAToken(
+			ctx,
+			savedUser,
+			enrolmentRequired,
+		)
 	}
 
-	return token, nil
+	return s.issueTokens(
+		ctx,
+		savedUser,
+	)
 }
 
-func (s *UserService) RegisterUser(
+// startLoginAttempt fails with a model.LockoutError while the key or the
+// ip is locked out, and otherwise returns the attempt to record.
+func (s *UserService) startLoginAttempt(
+	ctx context.Context,
+	lockoutKey string,
+	ipAddress string,
+) (model.LoginAttempt, error) {
+	now := time.Now()
+	lockedUntil, err := s.loginAttemptRepository.FindLockedUntil(
+		ctx,
+		lockoutKey,
+		ipAddress,
+		now,
+	)
+	if err != nil {
+		return model.LoginAttempt{}, err
+	}
+	if lockedUntil != nil {
+		return model.LoginAttempt{}, model.LockoutError{
+			LockedUntil: *lockedUntil,
+		}
+	}
+
+	attemptID, err := uuid.NewV7()
+	if err != nil {
+		return model.LoginAttempt{}, err
+	}
+
+	return model.LoginAttempt{
+		ID:        attemptID,
+		Username:  lockoutKey,
+		IPAddress: ipAddress,
+		CreatedAt: now,
+	}, nil
+}
+
+// recordLoginFailure returns constant.ErrBadInput, or a model.LockoutError
+// when this failure locked the username or the ip out.
+func (s *UserService) recordLoginFailure(
+	ctx context.Context,
+	attempt model.LoginAttempt,
+) error {
+	usernameFailures, ipFailures, err := s.loginAttemptRepository.RecordFailure(
+		ctx,
+		attempt,
+		s.loginConfig.FailureWindow,
+	)
+	if err != nil {
+		return err
+	}
+
+	usernameLockedUntil, err := s.lockOut(
+		ctx,
+		model.LockoutScopeUsername,
+		attempt.Username,
+		usernameFailures-s.loginConfig.MaxFailures,
+		attempt.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	ipLockedUntil, err := s.lockOut(
+		ctx,
+		model.LockoutScopeIP,
+		attempt.IPAddress,
+		ipFailures-s.loginConfig.MaxFailuresPerIP,
+		attempt.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	lockedUntil := usernameLockedUntil
+	if ipLockedUntil.After(lockedUntil) {
+		lockedUntil = ipLockedUntil
+	}
+	if lockedUntil.IsZero() {
+		return constant.ErrBadInput
+	}
+
+	return model.LockoutError{
+		LockedUntil: lockedUntil,
+	}
+}
+
+// lockOut locks the key once its failures reach the threshold, for a base
+// duration that doubles with every failure past it up to the configured
+// maximum. It returns the zero time when the key is not locked.
+func (s *UserService) lockOut(
+	ctx context.Context,
+	scope model.LockoutScope,
+	key string,
+	failuresPastThreshold int,
+	now time.Time,
+) (time.Time, error) {
+	if failuresPastThreshold < 0 {
+		return time.Time{}, nil
+	}
+
+	duration := s.loginConfig.LockoutBase
+	for range failuresPastThreshold {
+		if duration >= s.loginConfig.LockoutMax {
+			break
+		}
+		duration *= 2
+	}
+	lockedUntil := now.Add(
+		min(duration, s.loginConfig.LockoutMax),
+	)
+
+	err := s.loginAttemptRepository.Lock(
+		ctx,
+		scope,
+		key,
+		lockedUntil,
+	)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return lockedUntil, nil
+}
+
+// Unlock lifts the lockout of a username before it runs out.
+func (s *UserService) Unlock(
+	ctx context.Context,
+	username string,
+) error {
+	return s.loginAttemptRepository.Unlock(
+		ctx,
+		strings.ToLower(username),
+	)
+}
+
+// checkPassword fails with constant.ErrBadInput on a wrong password. A
+// correct password whose hash is outdated is rehashed with the current
+// settings; failing to store the new hash does not fail the login.
+func (s *UserService) checkPassword(
 	ctx context.Context,
 	user model.User,
-) (string, error) {
-	userId, err := uuid.NewV7()
+	plainPassword string,
+) error {
+	ok, err := s.passwordHasher.Verify(
+		user.Password,
+		plainPassword,
+	)
 	if err != nil {
-		return "", err
+		return err
+	}
+	if !ok {
+		return constant.ErrBadInput
 	}
 
-	generatedHashBytes, err := bcrypt.GenerateFromPassword(
-		[]byte(user.Password),
-		s.salt,
+	if !s.passwordHasher.NeedsRehash(user.Password) {
+		return nil
+	}
+	hash, err := s.passwordHasher.Hash(plainPassword)
+	if err != nil {
+		log.Printf("failed to rehash password of %s: %v", user.ID, err)
+		return nil
+	}
+	user.Password = hash
+	err = s.userRepository.UpdatePassword(
+		ctx,
+		user,
 	)
 	if err != nil {
-		return "", err
+		log.Printf("failed to store rehashed password of %s: %v", user.ID, err)
 	}
 
-	user.ID = userId
-	user.Password = string(
-		generatedHashBytes,
+	return nil
+}
+
+func (s *UserService) FindMe(
+	ctx context.Context,
+) (model.UserResponseBody, error) {
+	user, err := s.findMe(ctx)
+	if err != nil {
+		return model.UserResponseBody{}, err
+	}
+
+	return user.ToResponseBody(), nil
+}
+
+// UpdateMe changes the caller's account. A new email has to be verified
+// again, and a new password signs out every other session of the caller.
+func (s *UserService) UpdateMe(
+	ctx context.Context,
+	body model.UserPatchRequestBody,
+) (model.UserResponseBody, error) {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return model.UserResponseBody{}, err
+	}
+	user, err := s.findMe(ctx)
+	if err != nil {
+		return model.UserResponseBody{}, err
+	}
+
+	updated, err := body.Apply(user)
+	if err != nil {
+		return model.UserResponseBody{}, err
+	}
+
+	if body.Password != nil {
+		ok, err := s.passwordHasher.Verify(
+			user.Password,
+			body.CurrentPassword,
+		)
+		if err != nil {
+			return model.UserResponseBody{}, err
+		}
+		if !ok {
+			return model.UserResponseBody{}, constant.ErrBadInput
+		}
+
+		updated.Password, err = s.passwordHasher.Hash(
+			*body.Password,
+		)
+		if err != nil {
+			return model.UserResponseBody{}, err
+		}
+	}
+
+	emailChanged := updated.Email != user.Email
+	if emailChanged {
+		updated.EmailVerifiedAt = nil
+	}
+
+	err = s.userRepository.Update(
+		ctx,
+		updated,
 	)
-	savedUser, err := s.userRepository.CreateUser(
+	if err != nil {
+		return model.UserResponseBody{}, err
+	}
+
+	if body.Password != nil {
+		err := s.tokenRepository.RevokeOtherFamilies(
+			ctx,
+			user.ID,
+			principal.SessionID,
+			time.Now(),
+		)
+		if err != nil {
+			return model.UserResponseBody{}, err
+		}
+	}
+	if emailChanged {
+		err := s.sendVerificationEmail(
+			ctx,
+			updated,
+		)
+		if err != nil {
+			log.Printf("failed to send verification email to %s: %v", updated.ID, err)
+		}
+	}
+
+	return updated.ToResponseBody(), nil
+}
+
+func (s *UserService) findMe(
+	ctx context.Context,
+) (model.User, error) {
+	principal, err := model.PrincipalFromContext(ctx)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	user, err := s.userRepository.FindByID(
+		ctx,
+		model.User{
+			ID: principal.UserID,
+		},
+	)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+// ResendVerificationEmail mails the caller a new verification link unless
+// their email is already verified.
+func (s *UserService) ResendVerificationEmail(
+	ctx context.Context,
+) error {
+	user, err := s.findMe(ctx)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	return s.sendVerificationEmail(
 		ctx,
 		user,
 	)
+}
+
+func (s *UserService) VerifyEmail(
+	ctx context.Context,
+	token string,
+) error {
+	now := time.Now()
+	actionToken, err := s.consumeActionToken(
+		ctx,
+		token,
+		model.TokenPurposeVerifyEmail,
+		now,
+	)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	token, err := generateJwtToken(
-		s.secret,
-		savedUser,
+	return s.userRepository.MarkEmailVerified(
+		ctx,
+		model.User{
+			ID:              actionToken.UserID,
+			EmailVerifiedAt: &now,
+		},
+	)
+}
+
+// ForgotPassword mails a password reset link when an account with the
+// email exists. It succeeds either way so the response does not reveal
+// which emails are registered.
+func (s *UserService) ForgotPassword(
+	ctx context.Context,
+	email string,
+) error {
+	user, err := s.userRepository.FindByEmail(
+		ctx,
+		model.User{
+			Email: email,
+		},
 	)
 	if err != nil {
-		return "", err
+		if errors.Is(
+			err,
+			constant.ErrNotFound,
+		) {
+			return nil
+		}
+		return err
 	}
 
-	return token, nil
+	rawToken, expiresAt, err := s.issueActionToken(
+		ctx,
+		user,
+		model.TokenPurposeResetPassword,
+		s.mailConfig.PasswordResetTokenTTL,
+	)
+	if err != nil {
+		return err
+	}
+
+	// a failed mail answers the same as an unknown email, so the response
+	// never tells whether an account exists.
+	err = s.mailer.Send(
+		ctx,
+		mailer.Message{
+			To:      user.Email,
+			Subject: "Reset your BeliMang password",
+			Body: fmt.Sprintf(
+				"Hi %s,\n\n"+
+					"Someone asked to reset the password of your account. "+
+					"Choose a new password with the link below:\n\n"+
+					"%s/reset-password?token=%s\n\n"+
+					"The link works once and expires at %s. "+
+					"If you did not ask for this, you can ignore this email.\n",
+				user.Username,
+				strings.TrimRight(s.mailConfig.AppURL, "/"),
+				rawToken,
+				expiresAt.Format(time.RFC1123),
+			),
+		},
+	)
+	if err != nil {
+		log.Printf("failed to send password reset email to %s: %v", user.ID, err)
+	}
+
+	return nil
 }
 
-func (s *UserService) LoginUser(
+// ResetPassword sets a new password and signs the user out everywhere.
+// Since the token was mailed to the user, it also verifies their email.
+func (s *UserService) ResetPassword(
+	ctx context.Context,
+	token string,
+	newPassword string,
+) error {
+	now := time.Now()
+	actionToken, err := s.consumeActionToken(
+		ctx,
+		token,
+		model.TokenPurposeResetPassword,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+
+	hash, err := s.passwordHasher.Hash(
+		newPassword,
+	)
+	if err != nil {
+		return err
+	}
+
+	user := model.User{
+		ID:              actionToken.UserID,
+		Password:        hash,
+		EmailVerifiedAt: &now,
+	}
+	return s.userRepository.ResetPassword(
+		ctx,
+		user,
+		now,
+	)
+}
+
+func (s *UserService) sendVerificationEmail(
 	ctx context.Context,
 	user model.User,
-) (string, error) {
-	savedUser, err := s.userRepository.FindUserByUsername(
+) error {
+	rawToken, expiresAt, err := s.issueActionToken(
 		ctx,
 		user,
+		model.TokenPurposeVerifyEmail,
+		s.mailConfig.VerificationTokenTTL,
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(
+		ctx,
+		mailer.Message{
+			To:      user.Email,
+			Subject: "Verify your BeliMang email address",
+			Body: fmt.Sprintf(
+				"Hi %s,\n\n"+
+					"Confirm your email address with the link below:\n\n"+
+					"%s/verify-email?token=%s\n\n"+
+					"The link works once and expires at %s. "+
+					"If you did not create an account, you can ignore this email.\n",
+				user.Username,
+				strings.TrimRight(s.mailConfig.AppURL, "/"),
+				rawToken,
+				expiresAt.Format(time.RFC1123),
+			),
+		},
+	)
+}
+
+// issueActionToken stores a new single use token for the user and returns
+// the raw token to mail along with its expiry.
+func (s *UserService) issueActionToken(
+	ctx context.Context,
+	user model.User,
+	purpose model.TokenPurpose,
+	ttl time.Duration,
+) (string, time.Time, error) {
+	tokenID, err := uuid.NewV7()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	rawToken, err := generateOpaqueToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	actionToken := model.ActionToken{
+		ID:        tokenID,
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	err = s.tokenRepository.InsertActionToken(
+		ctx,
+		actionToken,
+	)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return rawToken, actionToken.ExpiresAt, nil
+}
+
+// consumeActionToken fails with constant.ErrBadInput when the token is
+// unknown, already used or expired.
+func (s *UserService) consumeActionToken(
+	ctx context.Context,
+	token string,
+	purpose model.TokenPurpose,
+	now time.Time,
+) (model.ActionToken, error) {
+	actionToken, err := s.tokenRepository.ConsumeActionToken(
+		ctx,
+		model.ActionToken{
+			TokenHash: hashToken(token),
+			Purpose:   purpose,
+		},
+		now,
 	)
 	if err != nil {
 		if errors.Is(
 			err,
 			constant.ErrNotFound,
 		) {
-			return "", constant.ErrBadInput
+			return model.ActionToken{}, constant.ErrBadInput
 		}
-		return "", err
+		return model.ActionToken{}, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword(
-		[]byte(savedUser.Password),
-		[]byte(user.Password),
-	); err != nil {
-		return "", constant.ErrBadInput
+	return actionToken, nil
+}
+
+// issueTokens starts a new session for the user with a fresh refresh token
+// family.
+func (s *UserService) issueTokens(
+	ctx context.Context,
+	user model.User,
+) (model.AuthTokens, error) {
+	familyID, err := uuid.NewV7()
+	if err != nil {
+		return model.AuthTokens{}, err
 	}
 
-	token, err := generateJwtToken(
-		s.secret,
-		savedUser,
+	now := time.Now()
+	refreshToken, rawRefreshToken, err := s.newRefreshToken(
+		user,
+		familyID,
+		now,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	err = s.tokenRepository.InsertRefreshToken(
+		ctx,
+		refreshToken,
+	)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	accessToken, err := generateJwtToken(
+		s.authConfig,
+		user,
+		familyID,
+		now,
 	)
 	if err != nil {
+		return model.AuthTokens{}, err
+	}
+
+	return model.AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: rawRefreshToken,
+	}, nil
+}
+
+// newRefreshToken returns the record to store, which only holds a hash, and
+// the raw token to hand out to the client.
+func (s *UserService) newRefreshToken(
+	user model.User,
+	familyID uuid.UUID,
+	now time.Time,
+) (model.RefreshToken, string, error) {
+	tokenID, err := uuid.NewV7()
+	if err != nil {
+		return model.RefreshToken{}, "", err
+	}
+
+	rawToken, err := generateOpaqueToken()
+	if err != nil {
+		return model.RefreshToken{}, "", err
+	}
+
+	return model.RefreshToken{
+		ID:        tokenID,
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: now.Add(s.authConfig.RefreshTokenTTL),
+		CreatedAt: now,
+	}, rawToken, nil
+}
+
+// generateOpaqueToken returns 256 random bits, url safe encoded.
+func generateOpaqueToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
 		return "", err
 	}
 
-	return token, nil
+	return base64.RawURLEncoding.EncodeToString(
+		raw,
+	), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 func generateJwtToken(
-	secret string,
+	authConfig config.AuthConfig,
 	user model.User,
+	sessionID uuid.UUID,
+	issuedAt time.Time,
 ) (string, error) {
-	token := jwt.New(
-		jwt.SigningMethodHS256,
-	)
+	tokenID, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
 
-	claims := token.Claims.(jwt.MapClaims)
-	userID := base64.RawStdEncoding.EncodeToString(
-		[]byte(user.ID.String()),
+	claims := model.NewAccessTokenClaims(
+		user,
+		sessionID,
+		tokenID,
 	)
-	email := base64.RawStdEncoding.EncodeToString(
-		[]byte(user.Email),
+	claims.Issuer = authConfig.Issuer
+	claims.Audience = jwt.ClaimStrings{authConfig.Audience}
+	claims.IssuedAt = jwt.NewNumericDate(issuedAt)
+	claims.ExpiresAt = jwt.NewNumericDate(
+		issuedAt.Add(authConfig.AccessTokenTTL),
 	)
-	username := base64.RawStdEncoding.EncodeToString(
-		[]byte(user.Username),
+
+	token := jwt.NewWithClaims(
+		authConfig.SigningMethod,
+		claims,
 	)
-	claims["si"] = userID
-	claims["mm"] = email
-	claims["rn"] = username
-	claims["exp"] = time.Now().
-		Add(time.Hour * 72).
-		Unix()
+	if authConfig.SigningKeyID != "" {
+		token.Header["kid"] = authConfig.SigningKeyID
+	}
 
 	t, err := token.SignedString(
-		[]byte(secret),
+		authConfig.SigningKey,
 	)
 	if err != nil {
 		log.Println(err)
@@ -218,3 +1142,7 @@ func generateJwtToken(
 
 	return t, nil
 }
+
+func (s *UserService) JWKS() config.JWKSet {
+	return s.authConfig.JWKS()
+}
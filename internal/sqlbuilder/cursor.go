@@ -0,0 +1,279 @@
+package sqlbuilder
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/validation"
+)
+
+// TimestampLayout writes timestamps into cursors the way postgres reads
+// them back into a timestamp column, to the microsecond.
+const TimestampLayout = "2006-01-02 15:04:05.999999"
+
+// ColumnType tells what a cursor value of a column has to look like, so a
+// tampered cursor is rejected before postgres fails to parse it.
+type ColumnType int
+
+const (
+	TextColumn ColumnType = iota
+	NumericColumn
+	TimestampColumn
+	UUIDColumn
+)
+
+// ColumnTypes maps the sortable columns of a listing to their types.
+// Columns missing from it are taken as text.
+type ColumnTypes map[string]ColumnType
+
+var numericValue = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+func (t ColumnType) valid(value string) bool {
+	switch t {
+	case NumericColumn:
+		return numericValue.MatchString(value)
+	case TimestampColumn:
+		_, err := time.Parse(TimestampLayout, value)
+		return err == nil
+	case UUIDColumn:
+		id, err := uuid.Parse(value)
+		return err == nil && id.String() == value
+	default:
+		return utf8.ValidString(value) && !strings.ContainsRune(value, 0)
+	}
+}
+
+// Cursor points at a row of a sorted listing through the values the row
+// has in the sorted columns. The values are kept as text, which postgres
+// parses back into whatever type the column has. A backward cursor pages
+// towards the start of the listing.
+type Cursor struct {
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// NewCursor makes a cursor for the row that value reads the columns of.
+func NewCursor(
+	sorts []Sort,
+	backward bool,
+	value func(column string) string,
+) Cursor {
+	values := make([]string, 0, len(sorts))
+	for _, sort := range sorts {
+		values = append(
+			values,
+			value(sort.column),
+		)
+	}
+
+	return Cursor{
+		Sort:     SortKey(sorts),
+		Values:   values,
+		Backward: backward,
+	}
+}
+
+// Encode returns the cursor as an opaque token for clients.
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor reads a token made by Encode. Tokens that are malformed,
+// hold values that do not fit the types of their columns or were made for
+// other sorts fail with a validation.Error for param.
+func DecodeCursor(
+	param string,
+	token string,
+	sorts []Sort,
+	types ColumnTypes,
+) (Cursor, error) {
+	var cursor Cursor
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(raw, &cursor)
+	}
+	valid := err == nil && len(cursor.Values) == len(sorts)
+	for i := 0; valid && i < len(sorts); i++ {
+		valid = types[sorts[i].column].valid(cursor.Values[i])
+	}
+	if !valid {
+		return Cursor{}, validation.New().Add(
+			param,
+			"invalid",
+			"must be a cursor returned by this listing",
+		).Err()
+	}
+	if cursor.Sort != SortKey(sorts) {
+		return Cursor{}, validation.New().Add(
+			param,
+			"sort_mismatch",
+			"was made for a different sort",
+		).Err()
+	}
+
+	return cursor, nil
+}
+
+// SortKey describes sorts, telling cursors of different sorts apart.
+func SortKey(sorts []Sort) string {
+	keys := make([]string, 0, len(sorts))
+	for _, sort := range sorts {
+		keys = append(
+			keys,
+			sort.column+" "+string(sort.direction),
+		)
+	}
+
+	return strings.Join(keys, ",")
+}
+
+// Reverse flips the direction of every sort, for reading a listing
+// backward.
+func Reverse(sorts []Sort) []Sort {
+	reversed := make([]Sort, 0, len(sorts))
+	for _, sort := range sorts {
+		direction := Asc
+		if sort.direction == Asc {
+			direction = Desc
+		}
+		reversed = append(
+			reversed,
+			SortBy(sort.column, direction),
+		)
+	}
+
+	return reversed
+}
+
+// Seek keeps the rows that come after the cursor in the order of sorts, or
+// before it for a backward cursor. sorts has to end with a unique column so
+// no two rows share a position.
+func (b *SelectBuilder) Seek(
+	sorts []Sort,
+	cursor Cursor,
+) *SelectBuilder {
+	if cursor.Backward {
+		sorts = Reverse(sorts)
+	}
+
+	uniform := true
+	for _, sort := range sorts {
+		uniform = uniform && sort.direction == sorts[0].direction
+	}
+	if uniform {
+		// a row comparison can be answered from an index on the columns
+		columns := make([]string, 0, len(sorts))
+		args := make([]any, 0, len(sorts))
+		for i, sort := range sorts {
+			columns = append(
+				columns,
+				sort.column,
+			)
+			args = append(
+				args,
+				cursor.Values[i],
+			)
+		}
+		return b.Where(
+			"("+strings.Join(columns, ", ")+") "+
+				seekOperator(sorts[0].direction)+
+				" ("+strings.TrimSuffix(strings.Repeat("?, ", len(sorts)), ", ")+")",
+			args...,
+		)
+	}
+
+	conds := make([]Condition, 0, len(sorts))
+	for i, sort := range sorts {
+		parts := make([]Condition, 0, i+1)
+		for j := range i {
+			parts = append(
+				parts,
+				Cond(sorts[j].column+" = ?", cursor.Values[j]),
+			)
+		}
+		parts = append(
+			parts,
+			Cond(
+				sort.column+" "+seekOperator(sort.direction)+" ?",
+				cursor.Values[i],
+			),
+		)
+		conds = append(
+			conds,
+			And(parts...),
+		)
+	}
+
+	return b.WhereCond(Or(conds...))
+}
+
+func seekOperator(direction Direction) string {
+	if direction == Asc {
+		return ">"
+	}
+	return "<"
+}
+
+// Page cuts rows, read after cursor with a limit one higher than wanted,
+// back to limit and puts them in the order of sorts again when paging
+// backward. It returns the tokens of the pages next to it, empty when
+// there is no such page.
+func Page[T any](
+	rows []T,
+	limit int,
+	sorts []Sort,
+	cursor *Cursor,
+	value func(row T, column string) string,
+) ([]T, string, string) {
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+
+	backward := cursor != nil && cursor.Backward
+	hasNext, hasPrev := more, cursor != nil
+	if backward {
+		slices.Reverse(rows)
+		hasNext, hasPrev = true, more
+	}
+
+	if len(rows) == 0 {
+		// nothing past the cursor, but the rows on its other side remain
+		if cursor == nil {
+			return rows, "", ""
+		}
+		back := Cursor{
+			Sort:     cursor.Sort,
+			Values:   cursor.Values,
+			Backward: !cursor.Backward,
+		}
+		if backward {
+			return rows, back.Encode(), ""
+		}
+		return rows, "", back.Encode()
+	}
+
+	var next, prev string
+	if hasNext {
+		last := rows[len(rows)-1]
+		next = NewCursor(sorts, false, func(column string) string {
+			return value(last, column)
+		}).Encode()
+	}
+	if hasPrev {
+		first := rows[0]
+		prev = NewCursor(sorts, true, func(column string) string {
+			return value(first, column)
+		}).Encode()
+	}
+
+	return rows, next, prev
+}
@@ -0,0 +1,282 @@
+// Package sqlbuilder composes select queries from conditions that mark
+// their arguments with ?, and numbers the placeholders when the query is
+// built. Only column names and expressions written in code reach the sql
+// text; everything coming from a request is passed as an argument or goes
+// through an allowlist such as SortFields.
+package sqlbuilder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Condition is a piece of a where clause.
+type Condition struct {
+	sql  string
+	args []any
+}
+
+func Cond(
+	sql string,
+	args ...any,
+) Condition {
+	return Condition{
+		sql:  sql,
+		args: args,
+	}
+}
+
+func And(conds ...Condition) Condition {
+	return join(" and ", conds)
+}
+
+func Or(conds ...Condition) Condition {
+	return join(" or ", conds)
+}
+
+// In holds when column is one of the values selected by query, which has
+// to select a single column.
+func In(
+	column string,
+	query *SelectBuilder,
+) Condition {
+	sub := query.Query()
+	return Cond(
+		column+" in ("+sub.sql+")",
+		sub.args...,
+	)
+}
+
+func join(
+	separator string,
+	conds []Condition,
+) Condition {
+	parts := make([]string, 0, len(conds))
+	var args []any
+	for _, cond := range conds {
+		parts = append(
+			parts,
+			cond.sql,
+		)
+		args = append(
+			args,
+			cond.args...,
+		)
+	}
+
+	return Condition{
+		sql:  "(" + strings.Join(parts, separator) + ")",
+		args: args,
+	}
+}
+
+type SelectBuilder struct {
+	columns    []Condition
+	from       Condition
+	conditions []Condition
+	orderBy    []Condition
+	limit      *int
+	offset     *int
+}
+
+func Select(columns ...string) *SelectBuilder {
+	b := &SelectBuilder{}
+	for _, column := range columns {
+		b.Column(column)
+	}
+	return b
+}
+
+// Column adds a selected expression that takes arguments, such as a score
+// computed from the request.
+func (b *SelectBuilder) Column(
+	expr string,
+	args ...any,
+) *SelectBuilder {
+	b.columns = append(
+		b.columns,
+		Cond(expr, args...),
+	)
+	return b
+}
+
+// From takes a table, or tables joined together.
+func (b *SelectBuilder) From(from string) *SelectBuilder {
+	b.from = Cond(from)
+	return b
+}
+
+// FromQuery selects from the rows of another query, such as one made with
+// UnionAll, under the name alias.
+func (b *SelectBuilder) FromQuery(
+	query Condition,
+	alias string,
+) *SelectBuilder {
+	b.from = Cond(
+		"("+query.sql+") "+alias,
+		query.args...,
+	)
+	return b
+}
+
+// UnionAll appends the rows of queries to each other.
+func UnionAll(queries ...*SelectBuilder) Condition {
+	parts := make([]Condition, 0, len(queries))
+	for _, query := range queries {
+		parts = append(
+			parts,
+			query.Query(),
+		)
+	}
+
+	return join(") union all (", parts)
+}
+
+// Where adds a condition that has to hold next to the ones added before.
+func (b *SelectBuilder) Where(
+	sql string,
+	args ...any,
+) *SelectBuilder {
+	return b.WhereCond(Cond(sql, args...))
+}
+
+func (b *SelectBuilder) WhereCond(cond Condition) *SelectBuilder {
+	b.conditions = append(
+		b.conditions,
+		cond,
+	)
+	return b
+}
+
+func (b *SelectBuilder) OrderBy(sorts ...Sort) *SelectBuilder {
+	for _, sort := range sorts {
+		b.orderBy = append(
+			b.orderBy,
+			Cond(sort.column+" "+string(sort.direction)),
+		)
+	}
+	return b
+}
+
+// OrderByExpr orders by an expression written in code, such as a
+// distance computed from arguments.
+func (b *SelectBuilder) OrderByExpr(
+	expr string,
+	args ...any,
+) *SelectBuilder {
+	b.orderBy = append(
+		b.orderBy,
+		Cond(expr, args...),
+	)
+	return b
+}
+
+func (b *SelectBuilder) Limit(limit int) *SelectBuilder {
+	b.limit = &limit
+	return b
+}
+
+func (b *SelectBuilder) Offset(offset int) *SelectBuilder {
+	b.offset = &offset
+	return b
+}
+
+// Count returns a query counting every row the conditions match, ignoring
+// the order and pagination.
+func (b *SelectBuilder) Count() *SelectBuilder {
+	return &SelectBuilder{
+		columns:    []Condition{Cond("count(*)")},
+		from:       b.from,
+		conditions: b.conditions,
+	}
+}
+
+// Query returns the query with its placeholders still unnumbered, to be
+// used inside another one.
+func (b *SelectBuilder) Query() Condition {
+	var sql strings.Builder
+	var args []any
+	write := func(cond Condition) {
+		sql.WriteString(cond.sql)
+		args = append(
+			args,
+			cond.args...,
+		)
+	}
+
+	sql.WriteString("select ")
+	for i, column := range b.columns {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		write(column)
+	}
+	sql.WriteString(" from ")
+	write(b.from)
+
+	for i, cond := range b.conditions {
+		if i == 0 {
+			sql.WriteString(" where ")
+		} else {
+			sql.WriteString(" and ")
+		}
+		write(cond)
+	}
+
+	for i, cond := range b.orderBy {
+		if i == 0 {
+			sql.WriteString(" order by ")
+		} else {
+			sql.WriteString(", ")
+		}
+		write(cond)
+	}
+
+	if b.limit != nil {
+		write(Cond(" limit ?", *b.limit))
+	}
+	if b.offset != nil {
+		write(Cond(" offset ?", *b.offset))
+	}
+
+	return Cond(sql.String(), args...)
+}
+
+// Build returns the sql with numbered placeholders and its arguments. It
+// panics when the query has a different number of ? than arguments, since
+// that is a mistake in the calling code.
+func (b *SelectBuilder) Build() (string, []any) {
+	var sql strings.Builder
+	args := writeCondition(&sql, b.Query(), nil)
+
+	return sql.String(), args
+}
+
+func writeCondition(
+	sql *strings.Builder,
+	cond Condition,
+	args []any,
+) []any {
+	placeholders := 0
+	for _, r := range cond.sql {
+		if r != '?' {
+			sql.WriteRune(r)
+			continue
+		}
+		placeholders++
+		fmt.Fprintf(sql, "$%d", len(args)+placeholders)
+	}
+	if placeholders != len(cond.args) {
+		panic(fmt.Sprintf(
+			"sqlbuilder: %q has %d placeholders but %d arguments",
+			cond.sql,
+			placeholders,
+			len(cond.args),
+		))
+	}
+
+	return append(
+		args,
+		cond.args...,
+	)
+}
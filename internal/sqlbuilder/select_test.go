@@ -0,0 +1,149 @@
+package sqlbuilder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildNumbersPlaceholdersInOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    *SelectBuilder
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			"where, limit and offset",
+			Select("id").From("merchants").Where(
+				"user_id = ?",
+				"u1",
+			).Where(
+				"name ilike '%' || ? || '%'",
+				"nasi",
+			).Limit(5).Offset(10),
+			"select id from merchants where user_id = $1 and name ilike '%' || $2 || '%' limit $3 offset $4",
+			[]any{"u1", "nasi", 5, 10},
+		},
+		{
+			"column arguments come before the where clause",
+			Select("id").Column(
+				"word_similarity(?, name) as score",
+				"nasi",
+			).From("merchants").Where("name = ?", "x"),
+			"select id, word_similarity($1, name) as score from merchants where name = $2",
+			[]any{"nasi", "x"},
+		},
+		{
+			"in with a paged subquery",
+			Select("o.id").From("orders o").WhereCond(
+				In(
+					"o.id",
+					Select("id").From("orders").Where(
+						"user_id = ?",
+						"u1",
+					).Limit(5).Offset(0),
+				),
+			).Where("o.created_at > ?", "t1"),
+			"select o.id from orders o where o.id in (select id from orders where user_id = $1 limit $2 offset $3) and o.created_at > $4",
+			[]any{"u1", 5, 0, "t1"},
+		},
+		{
+			"seek on uniform sorts uses a row comparison",
+			Select("id").From("merchants").Where(
+				"user_id = ?",
+				"u1",
+			).Seek(
+				[]Sort{SortBy("created_at", Desc), SortBy("id", Desc)},
+				Cursor{Values: []string{"2024-06-01 10:00:00", "m1"}},
+			).Limit(6),
+			"select id from merchants where user_id = $1 and (created_at, id) < ($2, $3) limit $4",
+			[]any{"u1", "2024-06-01 10:00:00", "m1", 6},
+		},
+		{
+			"seek backward flips the comparison",
+			Select("id").From("merchants").Seek(
+				[]Sort{SortBy("created_at", Desc), SortBy("id", Desc)},
+				Cursor{Values: []string{"2024-06-01 10:00:00", "m1"}, Backward: true},
+			),
+			"select id from merchants where (created_at, id) > ($1, $2)",
+			[]any{"2024-06-01 10:00:00", "m1"},
+		},
+		{
+			"seek on mixed sorts expands column by column",
+			Select("id").From("products").Seek(
+				[]Sort{SortBy("price", Asc), SortBy("id", Desc)},
+				Cursor{Values: []string{"12000", "p1"}},
+			).Limit(3),
+			"select id from products where ((price > $1) or (price = $2 and id < $3)) limit $4",
+			[]any{"12000", "12000", "p1", 3},
+		},
+		{
+			"union of subqueries",
+			Select("name").FromQuery(
+				UnionAll(
+					Select("name").From("merchants").Where("name = ?", "a"),
+					Select("name").From("products").Where("name = ?", "b"),
+				),
+				"hits",
+			).OrderByExpr("similarity(?, name) desc", "c").Limit(5),
+			"select name from ((select name from merchants where name = $1) union all (select name from products where name = $2)) hits order by similarity($3, name) desc limit $4",
+			[]any{"a", "b", "c", 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := tt.query.Build()
+			if sql != tt.wantSQL {
+				t.Errorf("Build() sql =\n%s\nwant\n%s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Build() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestCountKeepsConditionsAndDropsPagination(t *testing.T) {
+	query := Select("id", "name").From("merchants").Where(
+		"user_id = ?",
+		"u1",
+	)
+	count := query.Count()
+	query.OrderBy(SortBy("name", Asc)).Limit(5).Offset(10)
+
+	sql, args := count.Build()
+	wantSQL := "select count(*) from merchants where user_id = $1"
+	if sql != wantSQL {
+		t.Errorf("Count().Build() sql = %s, want %s", sql, wantSQL)
+	}
+	if !reflect.DeepEqual(args, []any{"u1"}) {
+		t.Errorf("Count().Build() args = %v, want [u1]", args)
+	}
+
+	sql, _ = query.Build()
+	wantSQL = "select id, name from merchants where user_id = $1 order by name asc limit $2 offset $3"
+	if sql != wantSQL {
+		t.Errorf("Build() sql = %s, want %s", sql, wantSQL)
+	}
+}
+
+func TestBuildPanicsOnMismatchedArguments(t *testing.T) {
+	tests := []struct {
+		name  string
+		query *SelectBuilder
+	}{
+		{"too few arguments", Select("id").From("merchants").Where("id = ? and name = ?", "m1")},
+		{"too many arguments", Select("id").From("merchants").Where("id = ?", "m1", "m2")},
+		{"argument without placeholder", Select("id").Column("name", "x").From("merchants")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Build() did not panic")
+				}
+			}()
+			tt.query.Build()
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package sqlbuilder
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/nozzlium/belimang/internal/validation"
+)
+
+type Direction string
+
+const (
+	Asc  Direction = "asc"
+	Desc Direction = "desc"
+)
+
+// Sort orders by one column. It can only be made with SortBy or
+// ParseSort, so its column is always one written in code.
+type Sort struct {
+	column    string
+	direction Direction
+}
+
+func SortBy(
+	column string,
+	direction Direction,
+) Sort {
+	if direction != Asc {
+		direction = Desc
+	}
+
+	return Sort{
+		column:    column,
+		direction: direction,
+	}
+}
+
+// SortFields maps the names clients sort by to the columns behind them.
+type SortFields map[string]string
+
+// ParseSort reads a comma separated list of sort keys, each one a name
+// from fields optionally prefixed with - to sort descending, such as
+// "-price,name". Unknown or repeated names fail with a validation.Error
+// for param.
+func ParseSort(
+	param string,
+	raw string,
+	fields SortFields,
+) ([]Sort, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	keys := strings.Split(raw, ",")
+	sorts := make([]Sort, 0, len(keys))
+	seen := make([]string, 0, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		direction := Asc
+		if name, found := strings.CutPrefix(key, "-"); found {
+			key = name
+			direction = Desc
+		}
+
+		column, ok := fields[key]
+		if !ok {
+			allowed := make([]string, 0, len(fields))
+			for name := range fields {
+				allowed = append(allowed, name)
+			}
+			slices.Sort(allowed)
+			return nil, validation.New().Add(
+				param,
+				"one_of",
+				"must only sort by "+strings.Join(allowed, ", "),
+			).Err()
+		}
+		if slices.Contains(seen, key) {
+			return nil, validation.New().Add(
+				param,
+				"duplicate",
+				"must not sort by "+key+" twice",
+			).Err()
+		}
+		seen = append(seen, key)
+
+		sorts = append(
+			sorts,
+			SortBy(column, direction),
+		)
+	}
+
+	return sorts, nil
+}
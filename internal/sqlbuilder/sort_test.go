@@ -0,0 +1,72 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/nozzlium/belimang/internal/validation"
+)
+
+func TestParseSort(t *testing.T) {
+	fields := SortFields{
+		"name":      "name",
+		"price":     "price",
+		"createdAt": "created_at",
+	}
+	tests := []struct {
+		name     string
+		raw      string
+		want     []Sort
+		wantCode string
+	}{
+		{"empty", "", nil, ""},
+		{"blank", "  ", nil, ""},
+		{
+			"ascending and descending",
+			"-price,name",
+			[]Sort{SortBy("price", Desc), SortBy("name", Asc)},
+			"",
+		},
+		{
+			"names map to columns",
+			" createdAt , -name ",
+			[]Sort{SortBy("created_at", Asc), SortBy("name", Desc)},
+			"",
+		},
+		{"unknown name", "price,deleted_at", nil, "one_of"},
+		{"column instead of name", "created_at", nil, "one_of"},
+		{"sql in the name", "name;drop table merchants", nil, "one_of"},
+		{"empty key", "name,", nil, "one_of"},
+		{"repeated name", "name,price,name", nil, "duplicate"},
+		{"repeated name in both directions", "price,-price", nil, "duplicate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSort("sort", tt.raw, fields)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("ParseSort(%q) error = %v", tt.raw, err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("ParseSort(%q) = %v, want %v", tt.raw, got, tt.want)
+				}
+				return
+			}
+
+			var verr validation.Error
+			if !errors.As(err, &verr) || len(verr.Fields) != 1 {
+				t.Fatalf("ParseSort(%q) error = %v, want one field error", tt.raw, err)
+			}
+			if field := verr.Fields[0]; field.Field != "sort" || field.Code != tt.wantCode {
+				t.Errorf(
+					"ParseSort(%q) field error = %s %s, want sort %s",
+					tt.raw,
+					field.Field,
+					field.Code,
+					tt.wantCode,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+)
+
+// Encrypt seals plaintext with AES-GCM under a 16, 24 or 32 byte key and
+// returns the nonce followed by the ciphertext, base64 encoded.
+func Encrypt(
+	key []byte,
+	plaintext []byte,
+) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(
+		gcm.Seal(nonce, nonce, plaintext, nil),
+	), nil
+}
+
+// Decrypt opens a value produced by Encrypt with the same key.
+func Decrypt(
+	key []byte,
+	sealed string,
+) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	return gcm.Open(
+		nil,
+		raw[:gcm.NonceSize()],
+		raw[gcm.NonceSize():],
+		nil,
+	)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,27 @@
+package util
+
+import "math"
+
+const earthRadiusKm = 6371.0
+
+// Haversine returns the great-circle distance in kilometers between two
+// points given in decimal degrees.
+func Haversine(
+	lat1, long1, lat2, long2 float64,
+) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLong := toRadians(long2 - long1)
+
+	a := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(toRadians(lat1))*
+			math.Cos(toRadians(lat2))*
+			math.Pow(math.Sin(dLong/2), 2)
+
+	return earthRadiusKm * 2 * math.Asin(
+		math.Sqrt(a),
+	)
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+)
+
+// word is a run of letters and digits, located by its rune offsets.
+type word struct {
+	text  string
+	start int
+	end   int
+}
+
+func splitWords(text string) []word {
+	var words []word
+	var current []rune
+	start := 0
+	offset := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if len(current) == 0 {
+				start = offset
+			}
+			current = append(current, unicode.ToLower(r))
+		} else if len(current) > 0 {
+			words = append(
+				words,
+				word{text: string(current), start: start, end: offset},
+			)
+			current = current[:0]
+		}
+		offset++
+	}
+	if len(current) > 0 {
+		words = append(
+			words,
+			word{text: string(current), start: start, end: offset},
+		)
+	}
+
+	return words
+}
+
+// trigrams pads a word the way pg_trgm does, two spaces in front and one
+// behind, and returns every three character slice of it.
+func trigrams(word string) map[string]struct{} {
+	padded := []rune("  " + word + " ")
+	set := make(map[string]struct{}, len(padded)-2)
+	for i := 0; i+3 <= len(padded); i++ {
+		set[string(padded[i:i+3])] = struct{}{}
+	}
+
+	return set
+}
+
+// TrigramSimilarity compares two words like the similarity function of
+// pg_trgm: the trigrams they share out of all the trigrams of both.
+func TrigramSimilarity(
+	a string,
+	b string,
+) float64 {
+	left := trigrams(strings.ToLower(a))
+	right := trigrams(strings.ToLower(b))
+
+	shared := 0
+	for trigram := range left {
+		if _, ok := right[trigram]; ok {
+			shared++
+		}
+	}
+	total := len(left) + len(right) - shared
+	if total == 0 {
+		return 0
+	}
+
+	return float64(shared) / float64(total)
+}
+
+// SimilarWords returns the rune offsets, start inclusive and end
+// exclusive, of the words in text that are at least threshold similar to
+// one of the words of query, so misspelt searches still point at what
+// they matched.
+func SimilarWords(
+	text string,
+	query string,
+	threshold float64,
+) [][2]int {
+	queryWords := splitWords(query)
+	matches := make([][2]int, 0)
+	for _, candidate := range splitWords(text) {
+		for _, queryWord := range queryWords {
+			if TrigramSimilarity(candidate.text, queryWord.text) >= threshold {
+				matches = append(
+					matches,
+					[2]int{candidate.start, candidate.end},
+				)
+				break
+			}
+		}
+	}
+
+	return matches
+}
@@ -0,0 +1,199 @@
+package validation
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/nozzlium/belimang/internal/util"
+)
+
+func Required(value string) Rule {
+	return func() *Violation {
+		if strings.TrimSpace(value) == "" {
+			return &Violation{
+				Code:    "required",
+				Message: "is required",
+			}
+		}
+		return nil
+	}
+}
+
+// NotNil fails for a nil pointer, telling an absent optional field apart
+// from its zero value.
+func NotNil[T any](value *T) Rule {
+	return func() *Violation {
+		if value == nil {
+			return &Violation{
+				Code:    "required",
+				Message: "is required",
+			}
+		}
+		return nil
+	}
+}
+
+// Length counts characters, not bytes.
+func Length(
+	value string,
+	min int,
+	max int,
+) Rule {
+	return func() *Violation {
+		if length := utf8.RuneCountInString(value); length < min ||
+			length > max {
+			return &Violation{
+				Code: "length",
+				Message: fmt.Sprintf(
+					"must be between %d and %d characters",
+					min,
+					max,
+				),
+			}
+		}
+		return nil
+	}
+}
+
+// MaxBytes counts bytes, for values whose size matters more than their
+// characters, like passwords handed to bcrypt.
+func MaxBytes(
+	value string,
+	max int,
+) Rule {
+	return func() *Violation {
+		if len(value) > max {
+			return &Violation{
+				Code: "length",
+				Message: fmt.Sprintf(
+					"must be at most %d bytes",
+					max,
+				),
+			}
+		}
+		return nil
+	}
+}
+
+func Min[T cmp.Ordered](
+	value T,
+	min T,
+) Rule {
+	return func() *Violation {
+		if value < min {
+			return &Violation{
+				Code: "min",
+				Message: fmt.Sprintf(
+					"must be at least %v",
+					min,
+				),
+			}
+		}
+		return nil
+	}
+}
+
+func Range[T cmp.Ordered](
+	value T,
+	min T,
+	max T,
+) Rule {
+	return func() *Violation {
+		if value < min || value > max {
+			return &Violation{
+				Code: "range",
+				Message: fmt.Sprintf(
+					"must be between %v and %v",
+					min,
+					max,
+				),
+			}
+		}
+		return nil
+	}
+}
+
+func Latitude(value float64) Rule {
+	return Range(value, -90, 90)
+}
+
+func Longitude(value float64) Rule {
+	return Range(value, -180, 180)
+}
+
+func OneOf[T comparable](
+	value T,
+	allowed ...T,
+) Rule {
+	return func() *Violation {
+		if !slices.Contains(allowed, value) {
+			options := make([]string, 0, len(allowed))
+			for _, option := range allowed {
+				options = append(
+					options,
+					fmt.Sprint(option),
+				)
+			}
+			return &Violation{
+				Code: "one_of",
+				Message: fmt.Sprintf(
+					"must be one of %s",
+					strings.Join(options, ", "),
+				),
+			}
+		}
+		return nil
+	}
+}
+
+func NotEmpty[T any](values []T) Rule {
+	return func() *Violation {
+		if len(values) == 0 {
+			return &Violation{
+				Code:    "required",
+				Message: "must not be empty",
+			}
+		}
+		return nil
+	}
+}
+
+func Email(value string) Rule {
+	return func() *Violation {
+		if util.ValidateEmailAddress(value) != nil {
+			return &Violation{
+				Code:    "email",
+				Message: "must be a valid email address",
+			}
+		}
+		return nil
+	}
+}
+
+func URL(value string) Rule {
+	return func() *Violation {
+		if util.ValidateURL(value) != nil {
+			return &Violation{
+				Code:    "url",
+				Message: "must be a valid url",
+			}
+		}
+		return nil
+	}
+}
+
+func UUID(value string) Rule {
+	return func() *Violation {
+		if _, err := uuid.Parse(value); err != nil {
+			return &Violation{
+				Code:    "uuid",
+				Message: "must be a valid id",
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,115 @@
+// Package validation checks request bodies field by field and reports every
+// failing field at once.
+//
+//	v := validation.New()
+//	v.Field("name", validation.Length(body.Name, 2, 30))
+//	v.Field("location.lat", validation.Latitude(body.Location.Lat))
+//	if err := v.Err(); err != nil {
+//		return err
+//	}
+package validation
+
+import (
+	"strings"
+
+	"github.com/nozzlium/belimang/internal/constant"
+)
+
+// FieldError tells which field of a request was rejected and why. Code is
+// meant for clients to branch on, Message for humans.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error carries every field error found in a request. It unwraps to
+// constant.ErrBadInput.
+type Error struct {
+	Fields []FieldError
+}
+
+func (e Error) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		fields = append(
+			fields,
+			field.Field+": "+field.Message,
+		)
+	}
+
+	return constant.ErrBadInput.Error() + ": " + strings.Join(fields, ", ")
+}
+
+func (e Error) Unwrap() error {
+	return constant.ErrBadInput
+}
+
+// Violation is what a failing Rule reports.
+type Violation struct {
+	Code    string
+	Message string
+}
+
+// Rule checks one value and returns nil when it is fine.
+type Rule func() *Violation
+
+type Validator struct {
+	fields []FieldError
+}
+
+func New() *Validator {
+	return &Validator{}
+}
+
+// Field runs the rules in order and records the first one that fails, so
+// every field reports at most one problem.
+func (v *Validator) Field(
+	field string,
+	rules ...Rule,
+) *Validator {
+	for _, rule := range rules {
+		if violation := rule(); violation != nil {
+			v.Add(
+				field,
+				violation.Code,
+				violation.Message,
+			)
+			break
+		}
+	}
+
+	return v
+}
+
+// Add records a failure found outside of the rules, such as a check
+// spanning several fields.
+func (v *Validator) Add(
+	field string,
+	code string,
+	message string,
+) *Validator {
+	v.fields = append(
+		v.fields,
+		FieldError{
+			Field:   field,
+			Code:    code,
+			Message: message,
+		},
+	)
+
+	return v
+}
+
+func (v *Validator) Valid() bool {
+	return len(v.fields) == 0
+}
+
+// Err returns an Error with every recorded failure, or nil.
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+
+	return Error{Fields: v.fields}
+}
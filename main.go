@@ -2,24 +2,43 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/bytedance/sonic"
 	"github.com/caarlos0/env/v11"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/nozzlium/belimang/internal/client"
 	"github.com/nozzlium/belimang/internal/config"
 	"github.com/nozzlium/belimang/internal/handler"
-	"github.com/nozzlium/belimang/internal/middleware"
 	"github.com/nozzlium/belimang/internal/repository"
+	"github.com/nozzlium/belimang/internal/router"
 	"github.com/nozzlium/belimang/internal/service"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "operator" {
+		err := runOperator(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	fiberApp := fiber.New(fiber.Config{
-		JSONEncoder: sonic.Marshal,
-		JSONDecoder: sonic.Unmarshal,
-		Prefork:     true,
+		JSONEncoder:  sonic.Marshal,
+		JSONDecoder:  sonic.Unmarshal,
+		Prefork:      true,
+		ErrorHandler: handler.ErrorHandler,
 	})
+	fiberApp.Use(requestid.New())
 
 	err := setupApp(fiberApp)
 	if err != nil {
@@ -32,42 +51,135 @@ func main() {
 	}
 }
 
-func setupApp(app *fiber.App) error {
+func loadConfig() (config.Config, error) {
 	var cfg config.Config
 	opts := env.Options{
 		TagName: "json",
 	}
-	if err := env.ParseWithOptions(&cfg, opts); err != nil {
+	err := env.ParseWithOptions(&cfg, opts)
+
+	return cfg, err
+}
+
+func setupApp(app *fiber.App) error {
+	cfg, err := loadConfig()
+	if err != nil {
 		log.Fatalf("%+v\n", err)
 		return err
 	}
 
+	authConfig, err := cfg.Auth()
+	if err != nil {
+		log.Fatal(err)
+		return err
+	}
+
+	passwordHasher, err := cfg.PasswordHasher()
+	if err != nil {
+		log.Fatal(err)
+		return err
+	}
+
+	mailer, err := cfg.Mailer()
+	if err != nil {
+		log.Fatal(err)
+		return err
+	}
+
+	mfaConfig, err := cfg.MFA()
+	if err != nil {
+		log.Fatal(err)
+		return err
+	}
+
+	err = cfg.Delivery.Validate()
+	if err != nil {
+		log.Fatal(err)
+		return err
+	}
+
+	err = cfg.Search.Validate()
+	if err != nil {
+		log.Fatal(err)
+		return err
+	}
+
 	db, err := client.InitDB(cfg.DB)
 	if err != nil {
 		log.Fatal(err)
 		return err
 	}
 
+	if cfg.MigrateOnStart {
+		err = migrateUp(db)
+		if err != nil {
+			log.Fatal(err)
+			return err
+		}
+	}
+
 	userRepository := repository.NewUserRepository(
 		db,
 	)
+	tokenRepository := repository.NewTokenRepository(
+		db,
+	)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(
+		db,
+	)
+	mfaRepository := repository.NewMFARepository(
+		db,
+	)
 	merchantRepository := repository.NewMerchantRepository(
 		db,
 	)
 	productRepository := repository.NewProductRepository(
 		db,
 	)
+	estimateRepository := repository.NewEstimateRepository(
+		db,
+	)
+	orderRepository := repository.NewOrderRepository(
+		db,
+	)
+	searchRepository := repository.NewSearchRepository(
+		db,
+	)
 
 	userService := service.NewUserService(
 		userRepository,
-		cfg.JWTSecret,
-		int(cfg.BCryptSalt),
+		tokenRepository,
+		loginAttemptRepository,
+		mfaRepository,
+		passwordHasher,
+		mailer,
+		authConfig,
+		cfg.Login,
+		cfg.Mail,
+		mfaConfig,
 	)
 	merchantService := service.NewMerchantService(
 		merchantRepository,
+		productRepository,
 	)
 	productService := service.NewProductService(
 		productRepository,
+		merchantRepository,
+	)
+	estimateService := service.NewEstimateService(
+		estimateRepository,
+		merchantRepository,
+		productRepository,
+		cfg.Delivery,
+	)
+	orderService := service.NewOrderService(
+		orderRepository,
+		estimateRepository,
+		userRepository,
+	)
+	searchService := service.NewSearchService(
+		searchRepository,
+		cfg.Search,
 	)
 
 	userHandler := handler.NewUserHandler(
@@ -79,44 +191,28 @@ func setupApp(app *fiber.App) error {
 	productHandler := handler.NewProductHandler(
 		productService,
 	)
-
-	admin := app.Group("/admin")
-	admin.Post(
-		"/register",
-		userHandler.RegisterAdmin,
-	)
-	admin.Post(
-		"/login",
-		userHandler.LoginAdmin,
+	estimateHandler := handler.NewEstimateHandler(
+		estimateService,
 	)
-	adminProtected := admin.Use(
-		middleware.Protected(),
-	).Use(middleware.SetClaimsData())
-	adminProtected.Post(
-		"/merchants",
-		merchantHandler.Create,
+	orderHandler := handler.NewOrderHandler(
+		orderService,
 	)
-	adminProtected.Get(
-		"/merchants",
-		merchantHandler.FindAll,
-	)
-	adminProtected.Post(
-		"/merchants/:merchantId/items",
-		productHandler.Create,
-	)
-	adminProtected.Get(
-		"/merchants/:merchantId/items",
-		productHandler.FindAll,
+	searchHandler := handler.NewSearchHandler(
+		searchService,
 	)
 
-	user := app.Group("/user")
-	user.Post(
-		"/register",
-		userHandler.RegisterUser,
-	)
-	user.Post(
-		"/login",
-		userHandler.LoginUser,
+	router.Register(
+		app,
+		authConfig,
+		userService,
+		router.Handlers{
+			User:     userHandler,
+			Merchant: merchantHandler,
+			Product:  productHandler,
+			Estimate: estimateHandler,
+			Order:    orderHandler,
+			Search:   searchHandler,
+		},
 	)
 
 	return nil
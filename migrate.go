@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nozzlium/belimang/db/migrate"
+	"github.com/nozzlium/belimang/internal/client"
+	"github.com/nozzlium/belimang/internal/migration"
+)
+
+const migrateUsage = `usage: belimang migrate <command>
+
+commands:
+  up            apply all pending migrations
+  down [N]      revert the last N migrations (default 1)
+  status        list migrations and whether they are applied
+  create NAME   create an empty up/down pair in db/migrate/primary`
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	if args[0] == "create" {
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		upPath, downPath, err := migration.Create(
+			"db/migrate/primary",
+			args[1],
+		)
+		if err != nil {
+			return err
+		}
+		fmt.Println(upPath)
+		fmt.Println(downPath)
+		return nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	db, err := client.InitDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		return migrateUp(db)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errors.New(migrateUsage)
+			}
+		}
+		return migrateDown(db, steps)
+	case "status":
+		return migrateStatus(db)
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+func migrateUp(db *pgxpool.Pool) error {
+	migrator, err := migration.New(
+		db,
+		migrate.Primary,
+	)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		return err
+	}
+	log.Printf("applied %d migration(s)", applied)
+
+	return nil
+}
+
+func migrateDown(
+	db *pgxpool.Pool,
+	steps int,
+) error {
+	migrator, err := migration.New(
+		db,
+		migrate.Primary,
+	)
+	if err != nil {
+		return err
+	}
+
+	reverted, err := migrator.Down(
+		context.Background(),
+		steps,
+	)
+	if err != nil {
+		return err
+	}
+	log.Printf("reverted %d migration(s)", reverted)
+
+	return nil
+}
+
+func migrateStatus(db *pgxpool.Pool) error {
+	migrator, err := migration.New(
+		db,
+		migrate.Primary,
+	)
+	if err != nil {
+		return err
+	}
+
+	statuses, dirty, err := migrator.Status(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		state := "pending"
+		if status.Applied {
+			state = "applied"
+		}
+		fmt.Printf(
+			"%-8s %d_%s\n",
+			state,
+			status.Version,
+			status.Name,
+		)
+	}
+	if dirty {
+		fmt.Println("database is dirty")
+	}
+
+	return nil
+}
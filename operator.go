@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nozzlium/belimang/internal/client"
+	"github.com/nozzlium/belimang/internal/model"
+	"github.com/nozzlium/belimang/internal/repository"
+)
+
+const operatorUsage = `usage: belimang operator <command> USERNAME
+
+commands:
+  grant USERNAME    make the admin USERNAME an operator
+  revoke USERNAME   take the operator role from USERNAME`
+
+// runOperator grants or revokes the operator role, which the api never
+// hands out, so only whoever runs the deployment decides who holds it.
+// The change applies to access tokens issued afterwards.
+func runOperator(args []string) error {
+	if len(args) != 2 || (args[0] != "grant" && args[0] != "revoke") {
+		return errors.New(operatorUsage)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	db, err := client.InitDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	userRepository := repository.NewUserRepository(
+		db,
+	)
+	user, err := userRepository.FindByUsername(
+		ctx,
+		model.User{
+			Username: args[1],
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to find %s: %w", args[1], err)
+	}
+
+	var done string
+	switch args[0] {
+	case "grant":
+		if !user.HasRole(model.RoleAdmin) {
+			return fmt.Errorf("%s is not an admin", user.Username)
+		}
+		err = userRepository.AddRole(
+			ctx,
+			user,
+			model.RoleOperator,
+			time.Now(),
+		)
+		done = "granted to"
+	case "revoke":
+		err = userRepository.RemoveRole(
+			ctx,
+			user,
+			model.RoleOperator,
+		)
+		done = "revoked from"
+	default:
+		return errors.New(operatorUsage)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("operator role %s %s", done, user.Username)
+
+	return nil
+}